@@ -0,0 +1,298 @@
+package simplejwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha256" // register SHA-256 for crypto.Hash
+	_ "crypto/sha512" // register SHA-384 and SHA-512 for crypto.Hash
+	"math/big"
+)
+
+// The supported signature algorithms, as used in the "alg" header
+const (
+	HS256 = "HS256"
+	HS384 = "HS384"
+	HS512 = "HS512"
+	RS256 = "RS256"
+	RS384 = "RS384"
+	RS512 = "RS512"
+	ES256 = "ES256"
+	ES384 = "ES384"
+	ES512 = "ES512"
+	EdDSA = "EdDSA"
+	PS256 = "PS256"
+	PS384 = "PS384"
+	PS512 = "PS512"
+)
+
+// algorithm is a signature algorithm that can sign and verify the signing input of a token
+type algorithm interface {
+	sign(key any, signingInput []byte) ([]byte, error)
+	verify(key any, signingInput, signature []byte) error
+
+	// keyType returns the JWK key type that the algorithm works with
+	keyType() string
+}
+
+var algorithms = map[string]algorithm{
+	HS256: hmacAlgorithm{crypto.SHA256},
+	HS384: hmacAlgorithm{crypto.SHA384},
+	HS512: hmacAlgorithm{crypto.SHA512},
+	RS256: rsaAlgorithm{crypto.SHA256},
+	RS384: rsaAlgorithm{crypto.SHA384},
+	RS512: rsaAlgorithm{crypto.SHA512},
+	ES256: ecdsaAlgorithm{crypto.SHA256, elliptic.P256()},
+	ES384: ecdsaAlgorithm{crypto.SHA384, elliptic.P384()},
+	ES512: ecdsaAlgorithm{crypto.SHA512, elliptic.P521()},
+	EdDSA: eddsaAlgorithm{},
+	PS256: rsaPSSAlgorithm{hash: crypto.SHA256},
+	PS384: rsaPSSAlgorithm{hash: crypto.SHA384},
+	PS512: rsaPSSAlgorithm{hash: crypto.SHA512},
+}
+
+// lookupAlgorithm returns the algorithm for the given "alg" header value
+func lookupAlgorithm(name string) (algorithm, error) {
+	alg, ok := algorithms[name]
+	if !ok {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	return alg, nil
+}
+
+// keyType returns the JWK key type of the given key. A key is only used with the
+// algorithms for its key type, so that a public key can never be used as an HMAC secret.
+func keyType(key any) string {
+	switch publicKey(key).(type) {
+	case []byte:
+		return "oct"
+	case *rsa.PublicKey:
+		return "RSA"
+	case *ecdsa.PublicKey:
+		return "EC"
+	case ed25519.PublicKey:
+		return "OKP"
+	}
+	return ""
+}
+
+// publicKey returns the public part of the given key, if it has one
+func publicKey(key any) any {
+	if signer, ok := key.(crypto.Signer); ok {
+		return signer.Public()
+	}
+	return key
+}
+
+// digest returns the hash of the given data
+func digest(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// checkKeyLength returns an error if the given algorithm is an HMAC algorithm
+// and the key is shorter than the hash output, as required by RFC 7518 section 3.2
+func checkKeyLength(alg algorithm, key any) error {
+	a, ok := alg.(hmacAlgorithm)
+	if !ok {
+		return nil
+	}
+	if secret, ok := key.([]byte); ok && len(secret) < a.hash.Size() {
+		return ErrKeyTooShort
+	}
+	return nil
+}
+
+// hmacAlgorithm implements HS256, HS384 and HS512 using a shared secret
+type hmacAlgorithm struct {
+	hash crypto.Hash
+}
+
+func (hmacAlgorithm) keyType() string {
+	return "oct"
+}
+
+func (a hmacAlgorithm) sign(key any, signingInput []byte) ([]byte, error) {
+	secret, ok := key.([]byte)
+	if !ok {
+		return nil, ErrKeyTypeMismatch
+	}
+	mac := hmac.New(a.hash.New, secret)
+	mac.Write(signingInput)
+	return mac.Sum(nil), nil
+}
+
+func (a hmacAlgorithm) verify(key any, signingInput, signature []byte) error {
+	expected, err := a.sign(key, signingInput)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(signature, expected) {
+		return ErrInvalidTokenSignature
+	}
+	return nil
+}
+
+// rsaAlgorithm implements RS256, RS384 and RS512 using RSASSA-PKCS1-v1_5
+type rsaAlgorithm struct {
+	hash crypto.Hash
+}
+
+func (rsaAlgorithm) keyType() string {
+	return "RSA"
+}
+
+func (a rsaAlgorithm) sign(key any, signingInput []byte) ([]byte, error) {
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrKeyTypeMismatch
+	}
+	return rsa.SignPKCS1v15(rand.Reader, privateKey, a.hash, digest(a.hash, signingInput))
+}
+
+func (a rsaAlgorithm) verify(key any, signingInput, signature []byte) error {
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return ErrKeyTypeMismatch
+	}
+	if rsa.VerifyPKCS1v15(pub, a.hash, digest(a.hash, signingInput), signature) != nil {
+		return ErrInvalidTokenSignature
+	}
+	return nil
+}
+
+// rsaPSSAlgorithm implements PS256, PS384 and PS512 using RSASSA-PSS.
+// RFC 7518 requires the salt to be as long as the hash output, and other salt lengths
+// are only accepted if anySaltLength is set, with WithAnyPSSSaltLength.
+type rsaPSSAlgorithm struct {
+	hash          crypto.Hash
+	anySaltLength bool
+}
+
+func (rsaPSSAlgorithm) keyType() string {
+	return "RSA"
+}
+
+func (a rsaPSSAlgorithm) sign(key any, signingInput []byte) ([]byte, error) {
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrKeyTypeMismatch
+	}
+	options := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: a.hash}
+	return rsa.SignPSS(rand.Reader, privateKey, a.hash, digest(a.hash, signingInput), options)
+}
+
+func (a rsaPSSAlgorithm) verify(key any, signingInput, signature []byte) error {
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return ErrKeyTypeMismatch
+	}
+	options := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: a.hash}
+	if a.anySaltLength {
+		options.SaltLength = rsa.PSSSaltLengthAuto
+	}
+	if rsa.VerifyPSS(pub, a.hash, digest(a.hash, signingInput), signature, options) != nil {
+		return ErrInvalidTokenSignature
+	}
+	return nil
+}
+
+// ecdsaAlgorithm implements ES256, ES384 and ES512.
+// Signatures are the raw R and S values, each padded to the size of the curve,
+// instead of the ASN.1 encoding that crypto/ecdsa uses.
+type ecdsaAlgorithm struct {
+	hash  crypto.Hash
+	curve elliptic.Curve
+}
+
+// size returns the number of bytes used for each of R and S
+func (a ecdsaAlgorithm) size() int {
+	return (a.curve.Params().BitSize + 7) / 8
+}
+
+func (ecdsaAlgorithm) keyType() string {
+	return "EC"
+}
+
+func (a ecdsaAlgorithm) sign(key any, signingInput []byte) ([]byte, error) {
+	privateKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok || privateKey.Curve != a.curve {
+		return nil, ErrKeyTypeMismatch
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, digest(a.hash, signingInput))
+	if err != nil {
+		return nil, err
+	}
+	size := a.size()
+	signature := make([]byte, 2*size)
+	r.FillBytes(signature[:size])
+	s.FillBytes(signature[size:])
+	return signature, nil
+}
+
+func (a ecdsaAlgorithm) verify(key any, signingInput, signature []byte) error {
+	pub, ok := key.(*ecdsa.PublicKey)
+	if !ok || pub.Curve != a.curve {
+		return ErrKeyTypeMismatch
+	}
+	size := a.size()
+	if len(signature) != 2*size {
+		return ErrInvalidTokenSignature
+	}
+	r := new(big.Int).SetBytes(signature[:size])
+	s := new(big.Int).SetBytes(signature[size:])
+	if !ecdsa.Verify(pub, digest(a.hash, signingInput), r, s) {
+		return ErrInvalidTokenSignature
+	}
+	return nil
+}
+
+// eddsaAlgorithm implements EdDSA with Ed25519 keys, as described in RFC 8037
+type eddsaAlgorithm struct{}
+
+func (eddsaAlgorithm) keyType() string {
+	return "OKP"
+}
+
+func (eddsaAlgorithm) sign(key any, signingInput []byte) ([]byte, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok || len(privateKey) != ed25519.PrivateKeySize {
+		return nil, ErrKeyTypeMismatch
+	}
+	return ed25519.Sign(privateKey, signingInput), nil
+}
+
+func (eddsaAlgorithm) verify(key any, signingInput, signature []byte) error {
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok || len(pub) != ed25519.PublicKeySize {
+		return ErrKeyTypeMismatch
+	}
+	if !ed25519.Verify(pub, signingInput, signature) {
+		return ErrInvalidTokenSignature
+	}
+	return nil
+}
+
+// noneAlgorithm implements unsecured tokens with "alg" set to "none" and an empty signature.
+// It is not in the algorithms map, and is only used by instances created with WithInsecureNoneAlgorithm.
+type noneAlgorithm struct{}
+
+func (noneAlgorithm) keyType() string {
+	return ""
+}
+
+func (noneAlgorithm) sign(_ any, _ []byte) ([]byte, error) {
+	return []byte{}, nil
+}
+
+func (noneAlgorithm) verify(_ any, _, signature []byte) error {
+	if len(signature) != 0 {
+		return ErrInvalidTokenSignature
+	}
+	return nil
+}
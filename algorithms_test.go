@@ -0,0 +1,288 @@
+package simplejwt_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xyproto/simplejwt"
+)
+
+func TestHMAC(t *testing.T) {
+	payload := simplejwt.Payload{
+		Subject: "1234567890",
+		Expires: time.Now().Add(time.Hour),
+	}
+
+	secret := strings.Repeat("s", 64)
+
+	for _, alg := range []string{simplejwt.HS256, simplejwt.HS384, simplejwt.HS512} {
+		jwt := simplejwt.New(simplejwt.WithSecret(secret), simplejwt.WithAlgorithm(alg), simplejwt.WithStrictKeyLength())
+		token, err := jwt.Generate(payload, nil)
+		if err != nil {
+			t.Fatalf("Failed to generate %s token: %v", alg, err)
+		}
+		if _, err := jwt.Validate(token); err != nil {
+			t.Fatalf("Failed to validate %s token: %v", alg, err)
+		}
+	}
+
+	// The algorithm can also be selected per token, if the validator allows it
+	jwt := simplejwt.New(simplejwt.WithSecret(secret), simplejwt.WithAllowedAlgorithms(simplejwt.HS256, simplejwt.HS512))
+	token, err := jwt.Generate(payload, &simplejwt.Header{Algorithm: simplejwt.HS512, Type: "JWT"})
+	if err != nil {
+		t.Fatalf("Failed to generate HS512 token: %v", err)
+	}
+	if _, err := jwt.Validate(token); err != nil {
+		t.Fatalf("Failed to validate HS512 token: %v", err)
+	}
+	if _, err := simplejwt.New(simplejwt.WithSecret(secret)).Validate(token); !errors.Is(err, simplejwt.ErrAlgorithmNotAllowed) {
+		t.Errorf("Expected ErrAlgorithmNotAllowed for HS512 when only HS256 is allowed, got %v", err)
+	}
+
+	// A 32 byte secret is long enough for HS256, but not for HS384 in strict mode
+	short := strings.Repeat("s", 32)
+	if _, err := simplejwt.New(simplejwt.WithSecret(short), simplejwt.WithStrictKeyLength()).Generate(payload, nil); err != nil {
+		t.Errorf("Expected a 32 byte secret to be accepted for HS256: %v", err)
+	}
+	if _, err := simplejwt.New(simplejwt.WithSecret(short), simplejwt.WithAlgorithm(simplejwt.HS384), simplejwt.WithStrictKeyLength()).Generate(payload, nil); err == nil {
+		t.Errorf("Expected a 32 byte secret to be refused for HS384")
+	}
+	if _, err := simplejwt.New(simplejwt.WithSecret("hunter1"), simplejwt.WithAlgorithm(simplejwt.HS512), simplejwt.WithStrictKeyLength()).Validate(token); !errors.Is(err, simplejwt.ErrKeyTooShort) {
+		t.Errorf("Expected a short secret to be refused when validating, got %v", err)
+	}
+}
+
+func TestRSA(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	payload := simplejwt.Payload{
+		Subject: "1234567890",
+		Expires: time.Now().Add(time.Hour),
+	}
+
+	for _, alg := range []string{simplejwt.RS256, simplejwt.RS384, simplejwt.RS512} {
+		issuer := simplejwt.New(simplejwt.WithSigningKey(alg, privateKey))
+		verifier := simplejwt.New(simplejwt.WithVerificationKey(&privateKey.PublicKey), simplejwt.WithAlgorithm(alg))
+
+		token, err := issuer.Generate(payload, nil)
+		if err != nil {
+			t.Fatalf("Failed to generate %s token: %v", alg, err)
+		}
+
+		decodedPayload, err := verifier.Validate(token)
+		if err != nil {
+			t.Fatalf("Failed to validate %s token: %v", alg, err)
+		}
+		if decodedPayload.Subject != payload.Subject {
+			t.Errorf("Expected Subject to be %s, got %s", payload.Subject, decodedPayload.Subject)
+		}
+	}
+
+	// A token signed with a shared secret must not validate against an RSA key
+	hmacToken, err := simplejwt.New(simplejwt.WithSecret("testsecret")).Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := simplejwt.New(simplejwt.WithVerificationKey(&privateKey.PublicKey)).Validate(hmacToken); err == nil {
+		t.Errorf("Expected HS256 token to be rejected by an RSA verifier")
+	}
+
+	// The "alg" header selects the algorithm, so RS256 with a shared secret is an error
+	if _, err := simplejwt.New().Generate(payload, &simplejwt.Header{Algorithm: simplejwt.RS256, Type: "JWT"}); err == nil {
+		t.Errorf("Expected RS256 with a shared secret to fail")
+	}
+}
+
+func TestRSAPSS(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	payload := simplejwt.Payload{
+		Subject: "1234567890",
+		Expires: time.Now().Add(time.Hour),
+	}
+
+	verifier := simplejwt.New(simplejwt.WithVerificationKey(&privateKey.PublicKey), simplejwt.WithAllowedAlgorithms(simplejwt.PS256, simplejwt.PS384, simplejwt.PS512))
+
+	for _, alg := range []string{simplejwt.PS256, simplejwt.PS384, simplejwt.PS512} {
+		token, err := simplejwt.New(simplejwt.WithSigningKey(alg, privateKey)).Generate(payload, nil)
+		if err != nil {
+			t.Fatalf("Failed to generate %s token: %v", alg, err)
+		}
+		if _, err := verifier.Validate(token); err != nil {
+			t.Fatalf("Failed to validate %s token: %v", alg, err)
+		}
+	}
+
+	// Sign a PS256 token the way a library using the maximum salt length would
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"PS256","typ":"JWT"}`))
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"other","exp":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`))
+	hashed := sha256.Sum256([]byte(header + "." + claims))
+	signature, err := rsa.SignPSS(rand.Reader, privateKey, crypto.SHA256, hashed[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	token := header + "." + claims + "." + base64.RawURLEncoding.EncodeToString(signature)
+
+	if _, err := verifier.Validate(token); !errors.Is(err, simplejwt.ErrInvalidTokenSignature) {
+		t.Errorf("Expected ErrInvalidTokenSignature for a PS256 token with another salt length, got %v", err)
+	}
+
+	// Other salt lengths can be accepted explicitly
+	lenient := simplejwt.New(simplejwt.WithVerificationKey(&privateKey.PublicKey), simplejwt.WithAllowedAlgorithms(simplejwt.PS256), simplejwt.WithAnyPSSSaltLength())
+	decodedPayload, err := lenient.Validate(token)
+	if err != nil {
+		t.Fatalf("Failed to validate PS256 token with another salt length: %v", err)
+	}
+	if decodedPayload.Subject != "other" {
+		t.Errorf("Expected Subject to be other, got %s", decodedPayload.Subject)
+	}
+}
+
+func TestECDSA(t *testing.T) {
+	payload := simplejwt.Payload{
+		Subject: "1234567890",
+		Expires: time.Now().Add(time.Hour),
+	}
+
+	curves := map[string]elliptic.Curve{
+		simplejwt.ES256: elliptic.P256(),
+		simplejwt.ES384: elliptic.P384(),
+		simplejwt.ES512: elliptic.P521(),
+	}
+
+	for alg, curve := range curves {
+		privateKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate ECDSA key: %v", err)
+		}
+
+		token, err := simplejwt.New(simplejwt.WithSigningKey(alg, privateKey)).Generate(payload, nil)
+		if err != nil {
+			t.Fatalf("Failed to generate %s token: %v", alg, err)
+		}
+
+		// The signature must be R || S, not ASN.1
+		parts := strings.Split(token, ".")
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			t.Fatalf("Failed to decode signature: %v", err)
+		}
+		if expected := 2 * ((curve.Params().BitSize + 7) / 8); len(signature) != expected {
+			t.Errorf("Expected %s signature to be %d bytes, got %d", alg, expected, len(signature))
+		}
+
+		if _, err := simplejwt.New(simplejwt.WithVerificationKey(&privateKey.PublicKey)).Validate(token); err != nil {
+			t.Fatalf("Failed to validate %s token: %v", alg, err)
+		}
+	}
+
+	// A P-256 key can not be used for ES384
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	if _, err := simplejwt.New(simplejwt.WithSigningKey(simplejwt.ES384, privateKey)).Generate(payload, nil); err == nil {
+		t.Errorf("Expected ES384 with a P-256 key to fail")
+	}
+}
+
+func TestEdDSA(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	payload := simplejwt.Payload{
+		Subject: "1234567890",
+		Expires: time.Now().Add(time.Hour),
+	}
+
+	token, err := simplejwt.New(simplejwt.WithSigningKey(simplejwt.EdDSA, privateKey)).Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	if header := mustDecodeSegment(t, token, 0); !strings.Contains(header, `"alg":"EdDSA"`) {
+		t.Errorf("Expected the header to contain alg EdDSA, got %s", header)
+	}
+
+	decodedPayload, err := simplejwt.New(simplejwt.WithVerificationKey(publicKey)).Validate(token)
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+	if decodedPayload.Subject != payload.Subject {
+		t.Errorf("Expected Subject to be %s, got %s", payload.Subject, decodedPayload.Subject)
+	}
+
+	otherPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+	if _, err := simplejwt.New(simplejwt.WithVerificationKey(otherPublicKey)).Validate(token); err == nil {
+		t.Errorf("Expected token to be rejected by another Ed25519 key")
+	}
+}
+
+func TestAlgorithmConfusion(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	payload := simplejwt.Payload{
+		Subject: "1234567890",
+		Expires: time.Now().Add(time.Hour),
+	}
+
+	// An HS256 token signed with the public key as the secret, as an attacker could do,
+	// is rejected even if the verifier allows both algorithms
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	forged, err := simplejwt.New(simplejwt.WithSecret(string(publicPEM))).Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	for _, verifier := range []*simplejwt.Instance{
+		simplejwt.New(simplejwt.WithVerificationKey(&privateKey.PublicKey), simplejwt.WithAllowedAlgorithms(simplejwt.RS256, simplejwt.HS256)),
+		simplejwt.New(simplejwt.WithVerificationKey(publicPEM), simplejwt.WithAllowedAlgorithms(simplejwt.RS256, simplejwt.HS256)),
+	} {
+		if _, err := verifier.Validate(forged); !errors.Is(err, simplejwt.ErrKeyTypeMismatch) {
+			t.Errorf("Expected ErrKeyTypeMismatch for an HS256 token signed with the public key, got %v", err)
+		}
+	}
+
+	// Unsecured tokens are only accepted when explicitly enabled
+	unsecured, err := simplejwt.New(simplejwt.WithInsecureNoneAlgorithm()).Generate(payload, &simplejwt.Header{Algorithm: "none", Type: "JWT"})
+	if err != nil {
+		t.Fatalf("Failed to generate unsecured token: %v", err)
+	}
+	if !strings.HasSuffix(unsecured, ".") {
+		t.Errorf("Expected an empty signature, got %s", unsecured)
+	}
+	if _, err := simplejwt.New(simplejwt.WithAllowedAlgorithms("none", simplejwt.HS256)).Validate(unsecured); !errors.Is(err, simplejwt.ErrAlgorithmNotAllowed) {
+		t.Errorf("Expected ErrAlgorithmNotAllowed for alg none, got %v", err)
+	}
+	if _, err := simplejwt.New(simplejwt.WithInsecureNoneAlgorithm()).Validate(unsecured); err != nil {
+		t.Errorf("Failed to validate unsecured token in test mode: %v", err)
+	}
+}
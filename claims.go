@@ -0,0 +1,211 @@
+package simplejwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Claims is a set of arbitrary claims, for tokens that carry more than a Payload
+type Claims map[string]any
+
+// Audience is the "aud" claim. It is encoded as a string if there is only one audience,
+// and can be decoded from both a string and an array of strings.
+type Audience []string
+
+// MarshalJSON encodes a single audience as a string and several audiences as an array
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// UnmarshalJSON decodes an audience from either a string or an array of strings.
+// Like for other types, null leaves the audience unchanged.
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = Audience(multiple)
+	return nil
+}
+
+// Contains checks if the given audience is one of the audiences
+func (a Audience) Contains(audience string) bool {
+	for _, aud := range a {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// NumericDate is a time that is encoded as the number of seconds since the Unix epoch,
+// as used by the "exp", "nbf" and "iat" claims. Fractional seconds are accepted when decoding.
+// For compatibility with tokens generated by earlier versions of this package,
+// RFC 3339 strings are also accepted when decoding.
+type NumericDate struct {
+	time.Time
+}
+
+// NewNumericDate returns a NumericDate for the given time
+func NewNumericDate(t time.Time) *NumericDate {
+	return &NumericDate{t}
+}
+
+// MarshalJSON encodes the time as whole seconds since the Unix epoch
+func (d NumericDate) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(d.Unix(), 10)), nil
+}
+
+// UnmarshalJSON decodes the time from a number of seconds since the Unix epoch,
+// or from an RFC 3339 string. Like for other types, null leaves the time unchanged.
+func (d *NumericDate) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return err
+		}
+		d.Time = t
+		return nil
+	}
+	f, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return err
+	}
+	// float64(math.MaxInt64) rounds up to 2^63, which is out of range
+	if f < math.MinInt64 || f >= math.MaxInt64 {
+		return fmt.Errorf("numeric date %s is out of range", data)
+	}
+	seconds, fraction := math.Modf(f)
+	d.Time = time.Unix(int64(seconds), int64(math.Round(fraction*1e9))).UTC()
+	return nil
+}
+
+// numericDate returns a NumericDate for the given time, or nil if the time is not set
+func numericDate(t time.Time) *NumericDate {
+	if t.IsZero() {
+		return nil
+	}
+	return NewNumericDate(t)
+}
+
+// timeOf returns the time of the given NumericDate, or the zero time if it is nil
+func timeOf(d *NumericDate) time.Time {
+	if d == nil {
+		return time.Time{}
+	}
+	return d.Time
+}
+
+// payloadJSON is the JSON representation of a Payload, with times as NumericDate values
+type payloadJSON struct {
+	Subject   string       `json:"sub"`
+	Expires   NumericDate  `json:"exp"`
+	Issuer    string       `json:"iss,omitempty"`
+	Audience  Audience     `json:"aud,omitempty"`
+	NotBefore *NumericDate `json:"nbf,omitempty"`
+	IssuedAt  *NumericDate `json:"iat,omitempty"`
+	ID        string       `json:"jti,omitempty"`
+}
+
+// MarshalJSON encodes the payload with "exp", "nbf" and "iat" as seconds since the Unix epoch,
+// leaving out "nbf" and "iat" if they are not set
+func (p Payload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(payloadJSON{
+		Subject:   p.Subject,
+		Expires:   NumericDate{p.Expires},
+		Issuer:    p.Issuer,
+		Audience:  p.Audience,
+		NotBefore: numericDate(p.NotBefore),
+		IssuedAt:  numericDate(p.IssuedAt),
+		ID:        p.ID,
+	})
+}
+
+// UnmarshalJSON decodes the payload, accepting both numeric and RFC 3339 times
+func (p *Payload) UnmarshalJSON(data []byte) error {
+	var decoded struct {
+		payloadJSON
+		Expires *NumericDate `json:"exp"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*p = Payload{
+		Subject:   decoded.Subject,
+		Expires:   timeOf(decoded.Expires),
+		Issuer:    decoded.Issuer,
+		Audience:  decoded.Audience,
+		NotBefore: timeOf(decoded.NotBefore),
+		IssuedAt:  timeOf(decoded.IssuedAt),
+		ID:        decoded.ID,
+	}
+	return nil
+}
+
+// registeredClaims holds the registered claims that are enforced for every token,
+// no matter which type the claims are decoded into
+type registeredClaims struct {
+	Expires   *NumericDate `json:"exp"`
+	NotBefore *NumericDate `json:"nbf"`
+	IssuedAt  *NumericDate `json:"iat"`
+	Issuer    string       `json:"iss"`
+	Audience  Audience     `json:"aud"`
+}
+
+// checkClaims enforces the registered claims of a decoded token payload
+func (inst *Instance) checkClaims(payloadBytes []byte) error {
+	var registered registeredClaims
+	if err := json.Unmarshal(payloadBytes, &registered); err != nil {
+		return ErrInvalidTokenPayload
+	}
+
+	now := inst.clock.Now()
+
+	if registered.Expires == nil {
+		return &ValidationError{Err: ErrMissingExpiry, Claim: "exp"}
+	}
+
+	if now.Add(-inst.leeway).Unix() > registered.Expires.Unix() {
+		return &ValidationError{Err: ErrTokenExpired, Claim: "exp", Time: registered.Expires.Time}
+	}
+
+	if registered.NotBefore != nil && now.Add(inst.leeway).Unix() < registered.NotBefore.Unix() {
+		return &ValidationError{Err: ErrTokenNotYetValid, Claim: "nbf", Time: registered.NotBefore.Time}
+	}
+
+	if registered.IssuedAt != nil && now.Add(inst.leeway).Unix() < registered.IssuedAt.Unix() {
+		return &ValidationError{Err: ErrTokenIssuedInFuture, Claim: "iat", Time: registered.IssuedAt.Time}
+	}
+
+	if inst.issuer != "" && registered.Issuer != inst.issuer {
+		return &ValidationError{Err: ErrInvalidIssuer, Claim: "iss", Expected: inst.issuer, Actual: []string{registered.Issuer}}
+	}
+
+	if inst.audience != "" && !registered.Audience.Contains(inst.audience) {
+		return &ValidationError{Err: ErrInvalidAudience, Claim: "aud", Expected: inst.audience, Actual: registered.Audience}
+	}
+
+	return nil
+}
@@ -0,0 +1,268 @@
+package simplejwt_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xyproto/simplejwt"
+)
+
+type customClaims struct {
+	Subject string                 `json:"sub"`
+	Expires *simplejwt.NumericDate `json:"exp"`
+	Roles   []string               `json:"roles"`
+	Tenant  string                 `json:"tenant"`
+}
+
+func TestCustomClaims(t *testing.T) {
+	jwt := simplejwt.New(simplejwt.WithSecret("testsecret"))
+
+	claims := customClaims{
+		Subject: "alice",
+		Expires: simplejwt.NewNumericDate(time.Now().Add(time.Hour)),
+		Roles:   []string{"admin", "user"},
+		Tenant:  "acme",
+	}
+
+	token, err := jwt.GenerateClaims(claims, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	var decoded customClaims
+	if err := jwt.ValidateInto(token, &decoded); err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+	if decoded.Tenant != "acme" || len(decoded.Roles) != 2 || decoded.Roles[0] != "admin" {
+		t.Errorf("Expected the custom claims to survive, got %+v", decoded)
+	}
+
+	decodedMap, err := jwt.ValidateClaims(token)
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+	if decodedMap["tenant"] != "acme" {
+		t.Errorf("Expected tenant to be acme, got %v", decodedMap["tenant"])
+	}
+
+	// The expiry is enforced for custom claims too
+	claims.Expires = simplejwt.NewNumericDate(time.Now().Add(-time.Hour))
+	token, err = jwt.GenerateClaims(claims, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if err := jwt.ValidateInto(token, &decoded); err == nil {
+		t.Errorf("Expected expired token to be rejected")
+	}
+
+	// Tokens without an expiry are rejected
+	token, err = jwt.GenerateClaims(simplejwt.Claims{"sub": "alice", "tenant": "acme"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := jwt.ValidateClaims(token); err == nil {
+		t.Errorf("Expected token without exp to be rejected")
+	}
+}
+
+func TestRegisteredClaims(t *testing.T) {
+	issuer := simplejwt.New(simplejwt.WithSecret("testsecret"))
+	verifier := simplejwt.New(
+		simplejwt.WithSecret("testsecret"),
+		simplejwt.WithRequiredIssuer("https://auth.example.com"),
+		simplejwt.WithRequiredAudience("api"),
+	)
+
+	payload := simplejwt.Payload{
+		Subject:  "alice",
+		Expires:  time.Now().Add(time.Hour),
+		Issuer:   "https://auth.example.com",
+		Audience: simplejwt.Audience{"api", "web"},
+		IssuedAt: time.Now(),
+		ID:       "abc123",
+	}
+
+	token, err := issuer.Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	decodedPayload, err := verifier.Validate(token)
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+	if decodedPayload.ID != payload.ID || len(decodedPayload.Audience) != 2 {
+		t.Errorf("Expected the registered claims to survive, got %+v", decodedPayload)
+	}
+
+	// A single audience is encoded as a string, and can be decoded from one
+	claims, err := verifier.ValidateClaims(token)
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+	if _, ok := claims["nbf"]; ok {
+		t.Errorf("Expected nbf to be left out when not set")
+	}
+	token, err = issuer.GenerateClaims(simplejwt.Claims{
+		"exp": simplejwt.NewNumericDate(time.Now().Add(time.Hour)),
+		"iss": "https://auth.example.com",
+		"aud": "api",
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := verifier.Validate(token); err != nil {
+		t.Errorf("Expected a string audience to be accepted: %v", err)
+	}
+
+	// A null audience is no audience, not an empty one
+	var audience simplejwt.Audience
+	if err := json.Unmarshal([]byte("null"), &audience); err != nil || audience != nil {
+		t.Errorf("Expected null to decode to no audience, got %q and %v", audience, err)
+	}
+	token, err = issuer.GenerateClaims(simplejwt.Claims{
+		"exp": simplejwt.NewNumericDate(time.Now().Add(time.Hour)),
+		"iss": "https://auth.example.com",
+		"aud": nil,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := verifier.Validate(token); !errors.Is(err, simplejwt.ErrInvalidAudience) {
+		t.Errorf("Expected ErrInvalidAudience for a null audience, got %v", err)
+	}
+
+	// Wrong issuer, wrong audience and a token that is not valid yet
+	for _, modify := range []func(*simplejwt.Payload){
+		func(p *simplejwt.Payload) { p.Issuer = "https://evil.example.com" },
+		func(p *simplejwt.Payload) { p.Audience = simplejwt.Audience{"other"} },
+		func(p *simplejwt.Payload) { p.NotBefore = time.Now().Add(time.Minute) },
+	} {
+		modified := payload
+		modify(&modified)
+		token, err := issuer.Generate(modified, nil)
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+		if _, err := verifier.Validate(token); err == nil {
+			t.Errorf("Expected token with payload %+v to be rejected", modified)
+		}
+	}
+}
+
+func TestNumericDate(t *testing.T) {
+	jwt := simplejwt.New(simplejwt.WithSecret("testsecret"))
+
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	token, err := jwt.Generate(simplejwt.Payload{Subject: "alice", Expires: expires}, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	claims, err := jwt.ValidateClaims(token)
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+	if exp, ok := claims["exp"].(float64); !ok || int64(exp) != expires.Unix() {
+		t.Errorf("Expected exp to be %d seconds since the epoch, got %v", expires.Unix(), claims["exp"])
+	}
+
+	// Fractional seconds are accepted
+	var d simplejwt.NumericDate
+	if err := json.Unmarshal([]byte("1700000000.5"), &d); err != nil {
+		t.Fatalf("Failed to decode NumericDate: %v", err)
+	}
+	if d.Unix() != 1700000000 || d.Nanosecond() != 500000000 {
+		t.Errorf("Expected 1700000000.5, got %v", d.Time)
+	}
+
+	// null leaves the time unchanged, and values that can not be represented are rejected
+	if err := json.Unmarshal([]byte("null"), &d); err != nil {
+		t.Errorf("Failed to decode a null NumericDate: %v", err)
+	} else if d.Unix() != 1700000000 {
+		t.Errorf("Expected null to leave the time unchanged, got %v", d.Time)
+	}
+	for _, value := range []string{"-1e30", "1e30", "9223372036854775808"} {
+		if err := json.Unmarshal([]byte(value), &d); err == nil {
+			t.Errorf("Expected an error for the out of range NumericDate %s, got %v", value, d.Time)
+		}
+	}
+
+	// Tokens issued with RFC 3339 strings, by earlier versions, are still accepted
+	token, err = jwt.GenerateClaims(map[string]any{"sub": "alice", "exp": expires}, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if !strings.Contains(mustDecodeSegment(t, token, 1), `"exp":"`) {
+		t.Fatalf("Expected exp to be encoded as a string")
+	}
+	payload, err := jwt.Validate(token)
+	if err != nil {
+		t.Fatalf("Failed to validate token with a string exp: %v", err)
+	}
+	if !payload.Expires.Equal(expires) {
+		t.Errorf("Expected Expires to be %v, got %v", expires, payload.Expires)
+	}
+}
+
+func TestLeewayAndClock(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	clock := simplejwt.ClockFunc(func() time.Time { return now })
+
+	jwt := simplejwt.New(simplejwt.WithSecret("testsecret"), simplejwt.WithClock(clock))
+	lenient := simplejwt.New(simplejwt.WithSecret("testsecret"), simplejwt.WithClock(clock), simplejwt.WithLeeway(5*time.Second))
+
+	// The clock is used when generating tokens too. SimpleGenerate counts days.
+	token := jwt.SimpleGenerate("alice", 1)
+	if token == "" {
+		t.Fatalf("Failed to generate token")
+	}
+	if jwt.SimpleValidate(token) != "alice" {
+		t.Fatalf("Failed to validate token")
+	}
+
+	now = now.Add(24*time.Hour + 2*time.Second)
+	if jwt.SimpleValidate(token) != "" {
+		t.Errorf("Expected token to have expired")
+	}
+	if lenient.SimpleValidate(token) != "alice" {
+		t.Errorf("Expected token to be accepted within the leeway")
+	}
+
+	now = now.Add(10 * time.Second)
+	if lenient.SimpleValidate(token) != "" {
+		t.Errorf("Expected token to have expired, even with the leeway")
+	}
+
+	// Tokens from an issuer whose clock is a few seconds ahead
+	payload := simplejwt.Payload{
+		Subject:   "alice",
+		Expires:   now.Add(time.Hour),
+		NotBefore: now.Add(3 * time.Second),
+		IssuedAt:  now.Add(3 * time.Second),
+	}
+	token, err := jwt.Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := jwt.Validate(token); !errors.Is(err, simplejwt.ErrTokenNotYetValid) {
+		t.Errorf("Expected ErrTokenNotYetValid, got %v", err)
+	}
+	if _, err := lenient.Validate(token); err != nil {
+		t.Errorf("Expected token to be accepted within the leeway: %v", err)
+	}
+
+	payload.NotBefore = time.Time{}
+	payload.IssuedAt = now.Add(time.Minute)
+	token, err = jwt.Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := lenient.Validate(token); !errors.Is(err, simplejwt.ErrTokenIssuedInFuture) {
+		t.Errorf("Expected ErrTokenIssuedInFuture, got %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+package simplejwt
+
+import "time"
+
+// Clock provides the current time when generating and validating tokens.
+// Tests can use their own Clock to simulate time passing.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is a function that can be used as a Clock
+type ClockFunc func() time.Time
+
+// Now returns the current time by calling the function
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// systemClock is the default Clock, that uses time.Now
+type systemClock struct{}
+
+// Now returns the current time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -43,8 +44,11 @@ func protectedHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	_, err := simplejwt.Validate(token)
-	if err != nil {
-		http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+	if errors.Is(err, simplejwt.ErrTokenExpired) {
+		http.Error(w, "Token has expired", http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
 		return
 	}
 
@@ -0,0 +1,79 @@
+package simplejwt
+
+import (
+	"encoding/json"
+)
+
+// CriticalHandler handles a header parameter that is listed in the "crit" header of a token.
+// It is given the JSON value of the parameter, and can reject the token by returning an error.
+type CriticalHandler func(value json.RawMessage) error
+
+// registeredHeaderParameters are the header parameters defined by RFC 7515, 7516 and 7518,
+// which can not be listed in the "crit" header
+var registeredHeaderParameters = map[string]bool{
+	"alg": true, "jku": true, "jwk": true, "kid": true, "x5u": true, "x5c": true, "x5t": true,
+	"x5t#S256": true, "typ": true, "cty": true, "crit": true, "enc": true, "zip": true,
+	"epk": true, "apu": true, "apv": true, "iv": true, "tag": true, "p2s": true, "p2c": true,
+}
+
+// WithCriticalHandler registers a handler for a header parameter that tokens may list in
+// their "crit" header. Tokens that list a parameter without a handler are rejected, as
+// required by RFC 7515 section 4.1.11. The "b64" parameter from RFC 7797 is always supported.
+func WithCriticalHandler(name string, handler CriticalHandler) Option {
+	return func(inst *Instance) {
+		if inst.criticalHandlers == nil {
+			inst.criticalHandlers = make(map[string]CriticalHandler)
+		}
+		inst.criticalHandlers[name] = handler
+	}
+}
+
+// UnmarshalJSON decodes a header, and keeps all of its parameters, so that the ones
+// listed in the "crit" header can be passed to the registered handlers
+func (h *Header) UnmarshalJSON(data []byte) error {
+	type header Header
+	if err := json.Unmarshal(data, (*header)(h)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &h.params)
+}
+
+// checkCritical checks that every parameter in the "crit" header is present and understood
+func (inst *Instance) checkCritical(header *Header) error {
+	if header.Critical == nil {
+		if _, ok := header.params["crit"]; ok {
+			return ErrInvalidTokenHeader
+		}
+		return nil
+	}
+	if len(header.Critical) == 0 {
+		return ErrInvalidTokenHeader
+	}
+	seen := make(map[string]bool)
+	for _, name := range header.Critical {
+		if seen[name] || registeredHeaderParameters[name] {
+			return ErrInvalidTokenHeader
+		}
+		seen[name] = true
+		if _, ok := header.params[name]; !ok {
+			return ErrInvalidTokenHeader
+		}
+		if _, ok := inst.criticalHandlers[name]; !ok && name != "b64" {
+			return ErrUnsupportedCriticalHeader
+		}
+	}
+	return nil
+}
+
+// handleCritical passes the parameters in the "crit" header to the registered handlers.
+// It is called after the signature has been verified.
+func (inst *Instance) handleCritical(header *Header) error {
+	for _, name := range header.Critical {
+		if handler, ok := inst.criticalHandlers[name]; ok {
+			if err := handler(header.params[name]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package simplejwt_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xyproto/simplejwt"
+)
+
+func TestCriticalHeader(t *testing.T) {
+	secret := strings.Repeat("s", 32)
+	payload := simplejwt.Payload{
+		Subject: "1234567890",
+		Expires: time.Now().Add(time.Hour),
+	}
+
+	// A token with a critical extension, built by hand since Header has no field for it
+	generate := func(header map[string]any) string {
+		headerBytes, _ := json.Marshal(header)
+		payloadBytes, _ := json.Marshal(payload)
+		unsigned := base64.RawURLEncoding.EncodeToString(headerBytes) + "." + base64.RawURLEncoding.EncodeToString(payloadBytes)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(unsigned))
+		return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+	token := generate(map[string]any{"alg": "HS256", "typ": "JWT", "crit": []string{"exp-tenant"}, "exp-tenant": "acme"})
+
+	// Without a handler, the token is rejected
+	if _, err := simplejwt.New(simplejwt.WithSecret(secret)).Validate(token); !errors.Is(err, simplejwt.ErrUnsupportedCriticalHeader) {
+		t.Errorf("Expected ErrUnsupportedCriticalHeader, got %v", err)
+	}
+
+	// With a handler, the token is accepted if the handler accepts the value
+	var tenant string
+	errWrongTenant := errors.New("wrong tenant")
+	jwt := simplejwt.New(simplejwt.WithSecret(secret), simplejwt.WithCriticalHandler("exp-tenant", func(value json.RawMessage) error {
+		if err := json.Unmarshal(value, &tenant); err != nil {
+			return err
+		}
+		if tenant != "acme" {
+			return errWrongTenant
+		}
+		return nil
+	}))
+	if _, err := jwt.Validate(token); err != nil {
+		t.Errorf("Failed to validate token with a handled critical header: %v", err)
+	}
+	if tenant != "acme" {
+		t.Errorf("Expected the handler to be given acme, got %q", tenant)
+	}
+	other := generate(map[string]any{"alg": "HS256", "crit": []string{"exp-tenant"}, "exp-tenant": "initech"})
+	if _, err := jwt.Validate(other); !errors.Is(err, errWrongTenant) {
+		t.Errorf("Expected the error from the handler, got %v", err)
+	}
+
+	// Malformed "crit" headers are rejected
+	for _, header := range []map[string]any{
+		{"alg": "HS256", "crit": []string{}},
+		{"alg": "HS256", "crit": []string{"exp-tenant"}},
+		{"alg": "HS256", "crit": []string{"alg"}},
+		{"alg": "HS256", "crit": []string{"exp-tenant", "exp-tenant"}, "exp-tenant": "acme"},
+		{"alg": "HS256", "crit": "exp-tenant", "exp-tenant": "acme"},
+	} {
+		if _, err := jwt.Validate(generate(header)); !errors.Is(err, simplejwt.ErrInvalidTokenHeader) {
+			t.Errorf("Expected ErrInvalidTokenHeader for %v, got %v", header, err)
+		}
+	}
+}
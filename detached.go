@@ -0,0 +1,116 @@
+package simplejwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// unencoded returns true if the header has "b64" set to false
+func (h *Header) unencoded() bool {
+	return h.Base64 != nil && !*h.Base64
+}
+
+// isCritical returns true if the given header parameter is listed in the "crit" header
+func (h *Header) isCritical(name string) bool {
+	for _, critical := range h.Critical {
+		if critical == name {
+			return true
+		}
+	}
+	return false
+}
+
+// payloadSegment returns the payload as it is signed and placed in a token.
+// It is base64url encoded, unless WithUnencodedPayload is used.
+func (inst *Instance) payloadSegment(payload []byte) string {
+	if inst.unencoded {
+		return string(payload)
+	}
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// decodePayload decodes the payload part of a token with the given header
+func (inst *Instance) decodePayload(header *Header, segment string) ([]byte, error) {
+	if header.unencoded() {
+		return []byte(segment), nil
+	}
+	return inst.decodeSegment(segment)
+}
+
+// SignDetached signs the given payload, with an optional custom header, and returns a token
+// where the payload part is left empty, as described in RFC 7515 appendix F. The payload is
+// sent separately, for example as the body of a webhook request, and must be given to
+// VerifyDetached or ValidateDetached. The payload can be any data, and does not need to be JSON.
+func (inst *Instance) SignDetached(payload []byte, customHeader *Header) (string, error) {
+	headerEncoded, signature, err := inst.sign(inst.payloadSegment(payload), customHeader)
+	if err != nil {
+		return "", err
+	}
+	return headerEncoded + ".." + signature, nil
+}
+
+// VerifyDetached checks the signature of a token that was generated with SignDetached,
+// for the given payload. No claims are checked, since the payload can be any data,
+// unless the "cty" header is "JWT", in which case the payload is validated like ValidateInto.
+// If the signature is not valid, the returned error is a *ValidationError.
+func (inst *Instance) VerifyDetached(token string, payload []byte) error {
+	header, err := inst.verifyDetached(token, payload)
+	if err != nil {
+		return validationError(err)
+	}
+	if mediaTypeEqual(header.ContentType, "JWT") {
+		var claims Claims
+		return inst.ValidateInto(string(payload), &claims)
+	}
+	if err := inst.checkType(&header); err != nil {
+		return validationError(err)
+	}
+	return nil
+}
+
+// ValidateDetached validates a token that was generated with SignDetached for a JSON payload
+// with claims, and decodes the claims into the value pointed to by claims, like ValidateInto.
+// If the token is not valid, the returned error is a *ValidationError.
+func (inst *Instance) ValidateDetached(token string, payload []byte, claims any) error {
+	header, err := inst.verifyDetached(token, payload)
+	if err != nil {
+		return validationError(err)
+	}
+
+	return inst.validatePayload(&header, payload, claims)
+}
+
+// verifyDetached checks the signature of a token with a detached payload, and returns its header
+func (inst *Instance) verifyDetached(token string, payload []byte) (Header, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return Header{}, ErrInvalidTokenFormat
+	}
+
+	headerBytes, err := inst.decodeSegment(parts[0])
+	if err != nil {
+		return Header{}, ErrInvalidTokenHeader
+	}
+
+	var header Header
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return Header{}, ErrInvalidTokenHeader
+	}
+
+	signature, err := inst.decodeSegment(parts[2])
+	if err != nil {
+		return Header{}, ErrInvalidTokenSignature
+	}
+
+	payloadEncoded := base64.RawURLEncoding.EncodeToString(payload)
+	if header.unencoded() {
+		payloadEncoded = string(payload)
+	}
+
+	if err := inst.verifySignature(&header, parts[0]+"."+payloadEncoded, signature); err != nil {
+		return Header{}, err
+	}
+
+	return header, nil
+}
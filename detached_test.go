@@ -0,0 +1,103 @@
+package simplejwt_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xyproto/simplejwt"
+)
+
+func TestDetachedPayload(t *testing.T) {
+	jwt := simplejwt.New(simplejwt.WithSecret(strings.Repeat("s", 32)))
+
+	body := []byte(`{"event":"push","repository":"simplejwt"}`)
+	token, err := jwt.SignDetached(body, nil)
+	if err != nil {
+		t.Fatalf("Failed to sign detached payload: %v", err)
+	}
+	if parts := strings.Split(token, "."); len(parts) != 3 || parts[1] != "" {
+		t.Fatalf("Expected an empty payload part, got %s", token)
+	}
+	if err := jwt.VerifyDetached(token, body); err != nil {
+		t.Errorf("Failed to verify detached payload: %v", err)
+	}
+	if err := jwt.VerifyDetached(token, []byte(`{"event":"delete","repository":"simplejwt"}`)); !errors.Is(err, simplejwt.ErrInvalidTokenSignature) {
+		t.Errorf("Expected ErrInvalidTokenSignature for another payload, got %v", err)
+	}
+
+	// Detached claims are validated like other tokens
+	claims, _ := json.Marshal(simplejwt.Payload{Subject: "1234567890", Expires: time.Now().Add(-time.Hour)})
+	token, err = jwt.SignDetached(claims, nil)
+	if err != nil {
+		t.Fatalf("Failed to sign detached claims: %v", err)
+	}
+	var payload simplejwt.Payload
+	if err := jwt.ValidateDetached(token, claims, &payload); !errors.Is(err, simplejwt.ErrTokenExpired) {
+		t.Errorf("Expected ErrTokenExpired for detached claims, got %v", err)
+	}
+}
+
+func TestUnencodedPayload(t *testing.T) {
+	// The examples from RFC 7797 section 4, with the key from RFC 7515 appendix A.1
+	key, _ := base64.RawURLEncoding.DecodeString("AyM1SysPpbyDfgZld3umj1qzKObwVMkoqQ-EstJQLr_T-1qS0gZH75aKtMN3Yj0iPS4hcgUuTwjAzZr1Z9CAow")
+	payload := []byte("$.02")
+	encoded := simplejwt.New(simplejwt.WithSecret(string(key)))
+	unencoded := simplejwt.New(simplejwt.WithSecret(string(key)), simplejwt.WithUnencodedPayload())
+
+	if err := encoded.VerifyDetached("eyJhbGciOiJIUzI1NiJ9..5mvfOroL-g7HyqJoozehmsaqmvTYGEq5jTI1gVvoEoQ", payload); err != nil {
+		t.Errorf("Failed to verify the RFC 7797 example with an encoded payload: %v", err)
+	}
+	rfcToken := "eyJhbGciOiJIUzI1NiIsImI2NCI6ZmFsc2UsImNyaXQiOlsiYjY0Il19..A5dxf2s96_n5FLueVuW1Z_vh161FwXZC4YLPff6dmDY"
+	if err := encoded.VerifyDetached(rfcToken, payload); err != nil {
+		t.Errorf("Failed to verify the RFC 7797 example with an unencoded payload: %v", err)
+	}
+
+	token, err := unencoded.SignDetached(payload, &simplejwt.Header{Algorithm: simplejwt.HS256})
+	if err != nil {
+		t.Fatalf("Failed to sign unencoded payload: %v", err)
+	}
+	if header := mustDecodeSegment(t, token, 0); !strings.Contains(header, `"b64":false`) || !strings.Contains(header, `"crit":["b64"]`) {
+		t.Errorf("Expected b64 and crit headers, got %s", header)
+	}
+	if err := encoded.VerifyDetached(token, payload); err != nil {
+		t.Errorf("Failed to verify unencoded payload: %v", err)
+	}
+
+	// The "crit" list of the given header is not changed, even if it has room for "b64"
+	critical := make([]string, 1, 2)
+	critical[0] = "exp"
+	if _, err := unencoded.SignDetached(payload, &simplejwt.Header{Critical: critical}); err != nil {
+		t.Fatalf("Failed to sign unencoded payload: %v", err)
+	}
+	if extended := critical[:2]; extended[1] != "" {
+		t.Errorf("Expected the crit list of the header to be left as it was, got %v", extended)
+	}
+
+	// "b64" must be listed in "crit"
+	withoutCrit := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","b64":false}`))
+	if err := encoded.VerifyDetached(withoutCrit+"..A5dxf2s96_n5FLueVuW1Z_vh161FwXZC4YLPff6dmDY", payload); !errors.Is(err, simplejwt.ErrInvalidTokenHeader) {
+		t.Errorf("Expected ErrInvalidTokenHeader without crit, got %v", err)
+	}
+
+	// Unencoded claims can be used in compact tokens, as long as they contain no periods
+	claims := simplejwt.Payload{Subject: "1234567890", Expires: time.Now().Add(time.Hour)}
+	token, err = unencoded.Generate(claims, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token with unencoded payload: %v", err)
+	}
+	validated, err := encoded.Validate(token)
+	if err != nil {
+		t.Fatalf("Failed to validate token with unencoded payload: %v", err)
+	}
+	if validated.Subject != claims.Subject {
+		t.Errorf("Expected subject %s, got %s", claims.Subject, validated.Subject)
+	}
+	claims.Subject = "a.b"
+	if _, err := unencoded.Generate(claims, nil); !errors.Is(err, simplejwt.ErrInvalidTokenPayload) {
+		t.Errorf("Expected ErrInvalidTokenPayload for a payload with a period, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package simplejwt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Errors that can be returned when generating or validating tokens.
+// When validating, they are wrapped in a *ValidationError, and can be checked with errors.Is.
+var (
+	ErrInvalidTokenFormat      = errors.New("invalid token format")
+	ErrInvalidTokenHeader      = errors.New("invalid token header")
+	ErrInvalidTokenSignature   = errors.New("invalid token signature")
+	ErrInvalidTokenPayload     = errors.New("invalid token payload")
+	ErrInvalidTokenType        = errors.New("invalid token type")
+	ErrTokenExpired            = errors.New("token has expired")
+	ErrMissingExpiry           = errors.New("token has no expiry")
+	ErrTokenNotYetValid        = errors.New("token is not valid yet")
+	ErrTokenIssuedInFuture     = errors.New("token is issued in the future")
+	ErrInvalidIssuer           = errors.New("invalid token issuer")
+	ErrInvalidAudience         = errors.New("invalid token audience")
+	ErrUnsupportedAlgorithm    = errors.New("unsupported algorithm")
+	ErrAlgorithmNotAllowed     = errors.New("algorithm is not allowed")
+	ErrKeyTypeMismatch         = errors.New("key type does not match algorithm")
+	ErrKeyTooShort             = errors.New("key is shorter than the hash output of the algorithm")
+	ErrUnsupportedKeyType      = errors.New("unsupported key type")
+	ErrInvalidKey              = errors.New("invalid key")
+	ErrKeyNotFound             = errors.New("no key found for token")
+	ErrNoActiveKey             = errors.New("no active key in keyring")
+	ErrInvalidCertificateChain = errors.New("invalid certificate chain")
+
+	// Errors from generating tokens in JWS JSON serialization
+	ErrNoSigners            = errors.New("no signers given")
+	ErrMixedPayloadEncoding = errors.New("either all or none of the signers must use WithUnencodedPayload")
+
+	// Errors from checking the "crit" header
+	ErrUnsupportedCriticalHeader = errors.New("unsupported critical header parameter")
+
+	// Errors from loading PEM and DER encoded keys
+	ErrNoPEMData             = errors.New("no PEM data found")
+	ErrEncryptedPrivateKey   = errors.New("the private key is encrypted")
+	ErrUnsupportedEncryption = errors.New("unsupported private key encryption")
+	ErrIncorrectPassword     = errors.New("incorrect password or corrupt private key")
+
+	// Errors from validating OpenID Connect ID tokens
+	ErrInvalidNonce           = errors.New("invalid ID token nonce")
+	ErrInvalidAuthorizedParty = errors.New("invalid ID token authorized party")
+	ErrInvalidAccessTokenHash = errors.New("access token does not match the ID token")
+	ErrMissingAuthTime        = errors.New("ID token has no auth_time")
+	ErrAuthTimeTooOld         = errors.New("authentication is older than the max age")
+	ErrMissingIDToken         = errors.New("token response has no id_token")
+
+	// Errors from encrypting and decrypting encrypted tokens
+	ErrNoEncryptionKey  = errors.New("no encryption key, use WithEncryptionKey")
+	ErrDecryptionFailed = errors.New("token could not be decrypted")
+)
+
+// ValidationError is returned when a token fails validation.
+// Err is one of the Err values above, and tells which check failed.
+// For the claim checks, Claim is the name of the claim, and either Time
+// or Expected and Actual give more context.
+type ValidationError struct {
+	Err      error
+	Claim    string
+	Time     time.Time
+	Expected string
+	Actual   []string
+}
+
+// validationError wraps the given error in a *ValidationError, unless it already is one
+func validationError(err error) error {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return err
+	}
+	return &ValidationError{Err: err}
+}
+
+// Error returns a description of the failed check
+func (e *ValidationError) Error() string {
+	switch {
+	case !e.Time.IsZero():
+		return fmt.Sprintf("%v (%s: %s)", e.Err, e.Claim, e.Time.Format(time.RFC3339))
+	case e.Expected != "":
+		return fmt.Sprintf("%v (%s: expected %q, got %q)", e.Err, e.Claim, e.Expected, strings.Join(e.Actual, ", "))
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error, so that errors.Is can be used to check which check failed
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
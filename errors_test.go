@@ -0,0 +1,53 @@
+package simplejwt_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xyproto/simplejwt"
+)
+
+func TestValidationErrors(t *testing.T) {
+	jwt := simplejwt.New(simplejwt.WithSecret("testsecret"), simplejwt.WithRequiredAudience("api"))
+
+	expires := time.Now().Add(-time.Minute).Truncate(time.Second)
+	token, err := jwt.Generate(simplejwt.Payload{Subject: "alice", Expires: expires, Audience: simplejwt.Audience{"api"}}, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	_, err = jwt.Validate(token)
+	if !errors.Is(err, simplejwt.ErrTokenExpired) {
+		t.Fatalf("Expected ErrTokenExpired, got %v", err)
+	}
+	var validationErr *simplejwt.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a *ValidationError, got %T", err)
+	}
+	if validationErr.Claim != "exp" || !validationErr.Time.Equal(expires) {
+		t.Errorf("Expected the expiry time %v in the error, got %+v", expires, validationErr)
+	}
+
+	// Wrong audience
+	token, err = jwt.Generate(simplejwt.Payload{Subject: "alice", Expires: time.Now().Add(time.Hour), Audience: simplejwt.Audience{"web"}}, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	_, err = jwt.Validate(token)
+	if !errors.As(err, &validationErr) || !errors.Is(err, simplejwt.ErrInvalidAudience) {
+		t.Fatalf("Expected ErrInvalidAudience, got %v", err)
+	}
+	if validationErr.Expected != "api" || len(validationErr.Actual) != 1 || validationErr.Actual[0] != "web" {
+		t.Errorf("Expected audience api and web in the error, got %+v", validationErr)
+	}
+
+	// A forged token is not mistaken for an expired one
+	_, err = simplejwt.New(simplejwt.WithSecret("othersecret")).Validate(token)
+	if !errors.Is(err, simplejwt.ErrInvalidTokenSignature) || errors.Is(err, simplejwt.ErrTokenExpired) {
+		t.Errorf("Expected ErrInvalidTokenSignature, got %v", err)
+	}
+	if !errors.As(err, &validationErr) {
+		t.Errorf("Expected a *ValidationError, got %T", err)
+	}
+}
@@ -0,0 +1,527 @@
+package simplejwt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+)
+
+// The supported key management algorithms for encrypted tokens, as used in the "alg" header
+const (
+	Direct     = "dir"
+	A128KW     = "A128KW"
+	A256KW     = "A256KW"
+	RSAOAEP256 = "RSA-OAEP-256"
+	ECDHES     = "ECDH-ES"
+)
+
+// The supported content encryption algorithms for encrypted tokens, as used in the "enc" header
+const (
+	A128GCM      = "A128GCM"
+	A256GCM      = "A256GCM"
+	A128CBCHS256 = "A128CBC-HS256"
+)
+
+// contentEncryption is a content encryption algorithm
+type contentEncryption interface {
+	keySize() int
+	encrypt(key, plaintext, aad []byte) (iv, ciphertext, tag []byte, err error)
+	decrypt(key, iv, ciphertext, tag, aad []byte) ([]byte, error)
+}
+
+var contentEncryptions = map[string]contentEncryption{
+	A128GCM:      gcmEncryption{16},
+	A256GCM:      gcmEncryption{32},
+	A128CBCHS256: cbcHMACEncryption{},
+}
+
+// Encrypt encrypts the plaintext into a JWE token in compact serialization, as described in RFC 7516.
+// alg is the key management algorithm and enc is the content encryption algorithm.
+// The key is a []byte for "dir", "A128KW" and "A256KW", an *rsa.PublicKey for "RSA-OAEP-256"
+// and an *ecdsa.PublicKey for "ECDH-ES". The header is optional, and can be used for setting
+// "typ", "cty" or "kid".
+func Encrypt(plaintext []byte, alg, enc string, key any, header *Header) (string, error) {
+	ce, ok := contentEncryptions[enc]
+	if !ok {
+		return "", ErrUnsupportedAlgorithm
+	}
+
+	jweHeader := Header{}
+	if header != nil {
+		jweHeader = *header
+	}
+	jweHeader.Algorithm = alg
+	jweHeader.Encryption = enc
+
+	cek, encryptedKey, err := encryptKey(&jweHeader, ce.keySize(), publicKey(key))
+	if err != nil {
+		return "", err
+	}
+
+	headerBytes, err := json.Marshal(jweHeader)
+	if err != nil {
+		return "", err
+	}
+	headerEncoded := encodeBytes(headerBytes)
+
+	iv, ciphertext, tag, err := ce.encrypt(cek, plaintext, []byte(headerEncoded))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		headerEncoded,
+		encodeBytes(encryptedKey),
+		encodeBytes(iv),
+		encodeBytes(ciphertext),
+		encodeBytes(tag),
+	}, "."), nil
+}
+
+// Decrypt decrypts a JWE token in compact serialization, and returns the plaintext and the header.
+// alg and enc are the key management and content encryption algorithms that the key is used
+// with, and tokens with other "alg" or "enc" headers are rejected with ErrAlgorithmNotAllowed,
+// so that a key is never used with an algorithm that the token chooses.
+// The key is a []byte for "dir", "A128KW" and "A256KW", an *rsa.PrivateKey for "RSA-OAEP-256"
+// and an *ecdsa.PrivateKey for "ECDH-ES". Compressed tokens, with a "zip" header, are not supported.
+func Decrypt(token, alg, enc string, key any) ([]byte, *Header, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		return nil, nil, ErrInvalidTokenFormat
+	}
+	decoded := make([][]byte, 5)
+	for i, part := range parts {
+		var err error
+		if decoded[i], err = base64.RawURLEncoding.DecodeString(part); err != nil {
+			return nil, nil, ErrInvalidTokenFormat
+		}
+	}
+
+	var header Header
+	if err := json.Unmarshal(decoded[0], &header); err != nil {
+		return nil, nil, ErrInvalidTokenHeader
+	}
+	// None of the extensions that can be listed in the "crit" header are supported for encrypted tokens
+	if _, ok := header.params["crit"]; ok {
+		return nil, nil, ErrUnsupportedCriticalHeader
+	}
+	// Compressed plaintexts are not supported
+	if _, ok := header.params["zip"]; ok {
+		return nil, nil, ErrUnsupportedAlgorithm
+	}
+	if header.Algorithm != alg || header.Encryption != enc {
+		return nil, nil, ErrAlgorithmNotAllowed
+	}
+	ce, ok := contentEncryptions[header.Encryption]
+	if !ok {
+		return nil, nil, ErrUnsupportedAlgorithm
+	}
+
+	cek, err := decryptKey(&header, ce.keySize(), decoded[1], key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plaintext, err := ce.decrypt(cek, decoded[2], decoded[3], decoded[4], []byte(parts[0]))
+	if err != nil {
+		return nil, nil, ErrDecryptionFailed
+	}
+	return plaintext, &header, nil
+}
+
+// encryptKey returns the content encryption key, and the encrypted key for the token.
+// For ECDH-ES, the "epk" header is set.
+func encryptKey(header *Header, size int, key any) (cek, encryptedKey []byte, err error) {
+	switch header.Algorithm {
+	case Direct:
+		secret, ok := key.([]byte)
+		if !ok {
+			return nil, nil, ErrKeyTypeMismatch
+		}
+		if len(secret) != size {
+			return nil, nil, ErrInvalidKey
+		}
+		return secret, nil, nil
+	case A128KW, A256KW:
+		kek, ok := key.([]byte)
+		if !ok {
+			return nil, nil, ErrKeyTypeMismatch
+		}
+		if len(kek) != keyWrapSize(header.Algorithm) {
+			return nil, nil, ErrInvalidKey
+		}
+		cek, err := randomBytes(size)
+		if err != nil {
+			return nil, nil, err
+		}
+		encryptedKey, err := aesKeyWrap(kek, cek)
+		return cek, encryptedKey, err
+	case RSAOAEP256:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, nil, ErrKeyTypeMismatch
+		}
+		cek, err := randomBytes(size)
+		if err != nil {
+			return nil, nil, err
+		}
+		encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, cek, nil)
+		return cek, encryptedKey, err
+	case ECDHES:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, nil, ErrKeyTypeMismatch
+		}
+		recipient, err := pub.ECDH()
+		if err != nil {
+			return nil, nil, ErrInvalidKey
+		}
+		ephemeral, err := ecdsa.GenerateKey(pub.Curve, rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		ephemeralECDH, err := ephemeral.ECDH()
+		if err != nil {
+			return nil, nil, err
+		}
+		z, err := ephemeralECDH.ECDH(recipient)
+		if err != nil {
+			return nil, nil, err
+		}
+		header.EphemeralPublicKey = &JWK{Key: &ephemeral.PublicKey}
+		return concatKDF(z, header.Encryption, nil, nil, size), nil, nil
+	}
+	return nil, nil, ErrUnsupportedAlgorithm
+}
+
+// decryptKey returns the content encryption key for a token. If an encrypted key can not be
+// decrypted, a random key is returned instead, so that the failure is not revealed until the
+// content is decrypted, as recommended by RFC 7516 section 11.5.
+func decryptKey(header *Header, size int, encryptedKey []byte, key any) ([]byte, error) {
+	switch header.Algorithm {
+	case Direct:
+		secret, ok := key.([]byte)
+		if !ok {
+			return nil, ErrKeyTypeMismatch
+		}
+		if len(encryptedKey) != 0 || len(secret) != size {
+			return nil, ErrDecryptionFailed
+		}
+		return secret, nil
+	case A128KW, A256KW:
+		kek, ok := key.([]byte)
+		if !ok {
+			return nil, ErrKeyTypeMismatch
+		}
+		if len(kek) != keyWrapSize(header.Algorithm) {
+			return nil, ErrDecryptionFailed
+		}
+		cek, err := aesKeyUnwrap(kek, encryptedKey)
+		if err != nil || len(cek) != size {
+			return randomBytes(size)
+		}
+		return cek, nil
+	case RSAOAEP256:
+		privateKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, ErrKeyTypeMismatch
+		}
+		cek, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, privateKey, encryptedKey, nil)
+		if err != nil || len(cek) != size {
+			return randomBytes(size)
+		}
+		return cek, nil
+	case ECDHES:
+		privateKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, ErrKeyTypeMismatch
+		}
+		if len(encryptedKey) != 0 || header.EphemeralPublicKey == nil {
+			return nil, ErrDecryptionFailed
+		}
+		ephemeral, ok := header.EphemeralPublicKey.Key.(*ecdsa.PublicKey)
+		if !ok || ephemeral.Curve != privateKey.Curve {
+			return nil, ErrDecryptionFailed
+		}
+		ephemeralECDH, err := ephemeral.ECDH()
+		if err != nil {
+			return nil, ErrDecryptionFailed
+		}
+		privateECDH, err := privateKey.ECDH()
+		if err != nil {
+			return nil, ErrInvalidKey
+		}
+		z, err := privateECDH.ECDH(ephemeralECDH)
+		if err != nil {
+			return nil, ErrDecryptionFailed
+		}
+		partyUInfo, err := header.partyInfo("apu")
+		if err != nil {
+			return nil, err
+		}
+		partyVInfo, err := header.partyInfo("apv")
+		if err != nil {
+			return nil, err
+		}
+		return concatKDF(z, header.Encryption, partyUInfo, partyVInfo, size), nil
+	}
+	return nil, ErrUnsupportedAlgorithm
+}
+
+// randomBytes returns n random bytes
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// keyWrapSize returns the key size for the given AES key wrap algorithm
+func keyWrapSize(alg string) int {
+	if alg == A128KW {
+		return 16
+	}
+	return 32
+}
+
+// partyInfo decodes the "apu" or "apv" header, which is used as the agreement party info
+// for ECDH-ES. A missing header is the same as empty party info.
+func (h *Header) partyInfo(name string) ([]byte, error) {
+	raw, ok := h.params[name]
+	if !ok {
+		return nil, nil
+	}
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err != nil {
+		return nil, ErrInvalidTokenHeader
+	}
+	info, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidTokenHeader
+	}
+	return info, nil
+}
+
+// concatKDF derives a key from a shared secret with the Concat KDF from NIST SP 800-56A,
+// as described in RFC 7518 section 4.6.2
+func concatKDF(z []byte, algorithmID string, partyUInfo, partyVInfo []byte, size int) []byte {
+	lengthPrefixed := func(data []byte) []byte {
+		return append(binary.BigEndian.AppendUint32(nil, uint32(len(data))), data...)
+	}
+	var otherInfo []byte
+	otherInfo = append(otherInfo, lengthPrefixed([]byte(algorithmID))...)
+	otherInfo = append(otherInfo, lengthPrefixed(partyUInfo)...)
+	otherInfo = append(otherInfo, lengthPrefixed(partyVInfo)...)
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(size*8))
+
+	var key []byte
+	for counter := uint32(1); len(key) < size; counter++ {
+		h := sha256.New()
+		h.Write(binary.BigEndian.AppendUint32(nil, counter))
+		h.Write(z)
+		h.Write(otherInfo)
+		key = h.Sum(key)
+	}
+	return key[:size]
+}
+
+// aesKeyWrapIV is the default initial value from RFC 3394
+var aesKeyWrapIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// aesKeyWrap wraps a key with AES, as described in RFC 3394
+func aesKeyWrap(kek, key []byte) ([]byte, error) {
+	if len(key)%8 != 0 || len(key) < 16 {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(key) / 8
+	a := append([]byte(nil), aesKeyWrapIV...)
+	r := append([]byte(nil), key...)
+	b := make([]byte, 16)
+	for j := 0; j < 6; j++ {
+		for i := 0; i < n; i++ {
+			copy(b, a)
+			copy(b[8:], r[i*8:i*8+8])
+			block.Encrypt(b, b)
+			t := uint64(n*j + i + 1)
+			binary.BigEndian.PutUint64(a, binary.BigEndian.Uint64(b[:8])^t)
+			copy(r[i*8:], b[8:])
+		}
+	}
+	return append(a, r...), nil
+}
+
+// aesKeyUnwrap unwraps a key that was wrapped with aesKeyWrap
+func aesKeyUnwrap(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped)%8 != 0 || len(wrapped) < 24 {
+		return nil, ErrDecryptionFailed
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(wrapped)/8 - 1
+	a := append([]byte(nil), wrapped[:8]...)
+	r := append([]byte(nil), wrapped[8:]...)
+	b := make([]byte, 16)
+	for j := 5; j >= 0; j-- {
+		for i := n - 1; i >= 0; i-- {
+			t := uint64(n*j + i + 1)
+			binary.BigEndian.PutUint64(b, binary.BigEndian.Uint64(a)^t)
+			copy(b[8:], r[i*8:i*8+8])
+			block.Decrypt(b, b)
+			copy(a, b[:8])
+			copy(r[i*8:], b[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(a, aesKeyWrapIV) != 1 {
+		return nil, ErrDecryptionFailed
+	}
+	return r, nil
+}
+
+// gcmEncryption implements A128GCM and A256GCM
+type gcmEncryption struct {
+	size int
+}
+
+func (e gcmEncryption) keySize() int {
+	return e.size
+}
+
+func (e gcmEncryption) encrypt(key, plaintext, aad []byte) (iv, ciphertext, tag []byte, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	iv, err = randomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	sealed := aead.Seal(nil, iv, plaintext, aad)
+	split := len(sealed) - aead.Overhead()
+	return iv, sealed[:split], sealed[split:], nil
+}
+
+func (e gcmEncryption) decrypt(key, iv, ciphertext, tag, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aead.NonceSize() || len(tag) != aead.Overhead() {
+		return nil, ErrDecryptionFailed
+	}
+	return aead.Open(nil, iv, append(append([]byte(nil), ciphertext...), tag...), aad)
+}
+
+// cbcHMACEncryption implements A128CBC-HS256, as described in RFC 7518 section 5.2
+type cbcHMACEncryption struct{}
+
+func (cbcHMACEncryption) keySize() int {
+	return 32
+}
+
+// tag computes the authentication tag from the associated data, IV and ciphertext
+func (cbcHMACEncryption) tag(macKey, aad, iv, ciphertext []byte) []byte {
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(aad)
+	mac.Write(iv)
+	mac.Write(ciphertext)
+	mac.Write(binary.BigEndian.AppendUint64(nil, uint64(len(aad))*8))
+	return mac.Sum(nil)[:16]
+}
+
+func (e cbcHMACEncryption) encrypt(key, plaintext, aad []byte) (iv, ciphertext, tag []byte, err error) {
+	macKey, encKey := key[:16], key[16:]
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	iv, err = randomBytes(aes.BlockSize)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	ciphertext = append(append([]byte(nil), plaintext...), make([]byte, padding)...)
+	for i := len(plaintext); i < len(ciphertext); i++ {
+		ciphertext[i] = byte(padding)
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
+	return iv, ciphertext, e.tag(macKey, aad, iv, ciphertext), nil
+}
+
+func (e cbcHMACEncryption) decrypt(key, iv, ciphertext, tag, aad []byte) ([]byte, error) {
+	macKey, encKey := key[:16], key[16:]
+	if !hmac.Equal(tag, e.tag(macKey, aad, iv, ciphertext)) {
+		return nil, ErrDecryptionFailed
+	}
+	if len(iv) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, ErrDecryptionFailed
+	}
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+	return unpad(plaintext, aes.BlockSize)
+}
+
+// GenerateEncrypted generates a signed JWT token with the given claims, like GenerateClaims,
+// and then encrypts it with the encryption key of the instance, with "cty" set to "JWT".
+func (inst *Instance) GenerateEncrypted(claims any, customHeader *Header) (string, error) {
+	if inst.encryptionKey == nil {
+		return "", ErrNoEncryptionKey
+	}
+	signed, err := inst.GenerateClaims(claims, customHeader)
+	if err != nil {
+		return "", err
+	}
+	header := &Header{Type: "JWT", ContentType: "JWT"}
+	return Encrypt([]byte(signed), inst.encryptionAlgorithm, inst.contentEncryption, inst.encryptionKey, header)
+}
+
+// ValidateEncryptedInto decrypts a token that was generated with GenerateEncrypted,
+// using the decryption key of the instance, and then validates the signed JWT token
+// inside of it, like ValidateInto.
+func (inst *Instance) ValidateEncryptedInto(token string, claims any) error {
+	plaintext, header, err := Decrypt(token, inst.decryptionAlgorithm, inst.decryptionEncryption, inst.decryptionKey)
+	if err != nil {
+		return validationError(err)
+	}
+	if !mediaTypeEqual(header.ContentType, "JWT") {
+		return validationError(ErrInvalidTokenHeader)
+	}
+	return inst.ValidateInto(string(plaintext), claims)
+}
+
+// ValidateEncrypted decrypts and validates a token that was generated with GenerateEncrypted,
+// and returns the decoded payload
+func (inst *Instance) ValidateEncrypted(token string) (Payload, error) {
+	var payload Payload
+	if err := inst.ValidateEncryptedInto(token, &payload); err != nil {
+		return Payload{}, err
+	}
+	return payload, nil
+}
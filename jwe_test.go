@@ -0,0 +1,206 @@
+package simplejwt_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xyproto/simplejwt"
+)
+
+func TestEncrypt(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	key16 := []byte(strings.Repeat("k", 16))
+	key32 := []byte(strings.Repeat("k", 32))
+
+	tests := []struct {
+		alg, enc   string
+		encryptKey any
+		decryptKey any
+	}{
+		{simplejwt.Direct, simplejwt.A256GCM, key32, key32},
+		{simplejwt.Direct, simplejwt.A128GCM, key16, key16},
+		{simplejwt.Direct, simplejwt.A128CBCHS256, key32, key32},
+		{simplejwt.A128KW, simplejwt.A256GCM, key16, key16},
+		{simplejwt.A256KW, simplejwt.A128CBCHS256, key32, key32},
+		{simplejwt.RSAOAEP256, simplejwt.A256GCM, &rsaKey.PublicKey, rsaKey},
+		{simplejwt.ECDHES, simplejwt.A128GCM, &ecKey.PublicKey, ecKey},
+	}
+	plaintext := []byte("The true sign of intelligence is not knowledge but imagination.")
+	for _, test := range tests {
+		token, err := simplejwt.Encrypt(plaintext, test.alg, test.enc, test.encryptKey, nil)
+		if err != nil {
+			t.Fatalf("Failed to encrypt with %s and %s: %v", test.alg, test.enc, err)
+		}
+		if parts := strings.Split(token, "."); len(parts) != 5 {
+			t.Fatalf("Expected 5 parts with %s and %s, got %d", test.alg, test.enc, len(parts))
+		}
+		decrypted, header, err := simplejwt.Decrypt(token, test.alg, test.enc, test.decryptKey)
+		if err != nil {
+			t.Fatalf("Failed to decrypt with %s and %s: %v", test.alg, test.enc, err)
+		}
+		if string(decrypted) != string(plaintext) {
+			t.Errorf("Expected %q with %s and %s, got %q", plaintext, test.alg, test.enc, decrypted)
+		}
+		if header.Algorithm != test.alg || header.Encryption != test.enc {
+			t.Errorf("Expected alg %s and enc %s, got %s and %s", test.alg, test.enc, header.Algorithm, header.Encryption)
+		}
+
+		// Changing any byte of the ciphertext must make decryption fail
+		parts := strings.Split(token, ".")
+		ciphertext, _ := base64.RawURLEncoding.DecodeString(parts[3])
+		ciphertext[0] ^= 1
+		parts[3] = base64.RawURLEncoding.EncodeToString(ciphertext)
+		if _, _, err := simplejwt.Decrypt(strings.Join(parts, "."), test.alg, test.enc, test.decryptKey); !errors.Is(err, simplejwt.ErrDecryptionFailed) {
+			t.Errorf("Expected ErrDecryptionFailed for a modified token with %s and %s, got %v", test.alg, test.enc, err)
+		}
+	}
+
+	// A wrong key must make decryption fail
+	token, err := simplejwt.Encrypt(plaintext, simplejwt.A256KW, simplejwt.A256GCM, key32, nil)
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	if _, _, err := simplejwt.Decrypt(token, simplejwt.A256KW, simplejwt.A256GCM, []byte(strings.Repeat("x", 32))); !errors.Is(err, simplejwt.ErrDecryptionFailed) {
+		t.Errorf("Expected ErrDecryptionFailed for a wrong key, got %v", err)
+	}
+
+	// A key for A256KW can not be used directly as the content encryption key, or with another enc
+	for _, other := range [][2]string{{simplejwt.Direct, simplejwt.A256GCM}, {simplejwt.A256KW, simplejwt.A128CBCHS256}} {
+		token, err := simplejwt.Encrypt(plaintext, other[0], other[1], key32, nil)
+		if err != nil {
+			t.Fatalf("Failed to encrypt with %s and %s: %v", other[0], other[1], err)
+		}
+		if _, _, err := simplejwt.Decrypt(token, simplejwt.A256KW, simplejwt.A256GCM, key32); !errors.Is(err, simplejwt.ErrAlgorithmNotAllowed) {
+			t.Errorf("Expected ErrAlgorithmNotAllowed for %s and %s, got %v", other[0], other[1], err)
+		}
+	}
+
+	// The example from RFC 7516 appendix A.3, with A128KW and A128CBC-HS256
+	rfcKey, _ := base64.RawURLEncoding.DecodeString("GawgguFyGrWKav7AX4VKUg")
+	rfcToken := "eyJhbGciOiJBMTI4S1ciLCJlbmMiOiJBMTI4Q0JDLUhTMjU2In0." +
+		"6KB707dM9YTIgHtLvtgWQ8mKwboJW3of9locizkDTHzBC2IlrT1oOQ." +
+		"AxY8DCtDaGlsbGljb3RoZQ." +
+		"KDlTtXchhZTGufMYmOYGS4HffxPSUrfmqCHXaI9wOGY." +
+		"U0m_YmjN04DJvceFICbCVQ"
+	decrypted, _, err := simplejwt.Decrypt(rfcToken, simplejwt.A128KW, simplejwt.A128CBCHS256, rfcKey)
+	if err != nil {
+		t.Fatalf("Failed to decrypt the RFC 7516 example: %v", err)
+	}
+	if string(decrypted) != "Live long and prosper." {
+		t.Errorf("Expected the RFC 7516 plaintext, got %q", decrypted)
+	}
+
+	// The keys and the "apu" and "apv" headers from the ECDH-ES example in RFC 7518 appendix C,
+	// where the derived content encryption key is given
+	var bob simplejwt.JWK
+	if err := json.Unmarshal([]byte(`{"kty":"EC","crv":"P-256",`+
+		`"x":"weNJy2HscCSM6AEDTDg04biOvhFhyyWvOHQfeF_PxMQ",`+
+		`"y":"e8lnCO-AlStT-NJVX-crhB7QRYhiix03illJOVAOyck",`+
+		`"d":"VEmDZpDXXK8p8N0Cndsxs924q6nS1RXFASRl6BfUqdw"}`), &bob); err != nil {
+		t.Fatalf("Failed to decode the RFC 7518 key: %v", err)
+	}
+	rfcHeader := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ECDH-ES","enc":"A128GCM","apu":"QWxpY2U","apv":"Qm9i",` +
+		`"epk":{"kty":"EC","crv":"P-256","x":"gI0GAILBdu7T53akrFmMyGcsF3n5dO7MmwNBHKW5SV0","y":"SLW_xSffzlPWrHEVI30DHM_4egVwt3NQqeUD7nMFpps"}}`))
+	cek, _ := base64.RawURLEncoding.DecodeString("VqqN6vgjbSBcIijNcacQGg")
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("Failed to create GCM: %v", err)
+	}
+	iv := make([]byte, aead.NonceSize())
+	sealed := aead.Seal(nil, iv, plaintext, []byte(rfcHeader))
+	split := len(sealed) - aead.Overhead()
+	rfcToken = rfcHeader + ".." + base64.RawURLEncoding.EncodeToString(iv) + "." +
+		base64.RawURLEncoding.EncodeToString(sealed[:split]) + "." + base64.RawURLEncoding.EncodeToString(sealed[split:])
+	decrypted, _, err = simplejwt.Decrypt(rfcToken, simplejwt.ECDHES, simplejwt.A128GCM, bob.Key)
+	if err != nil {
+		t.Fatalf("Failed to decrypt a token with apu and apv: %v", err)
+	}
+	if string(decrypted) != string(plaintext) {
+		t.Errorf("Expected %q, got %q", plaintext, decrypted)
+	}
+
+	// Compressed tokens are not supported
+	zipped, err := simplejwt.Encrypt(plaintext, simplejwt.Direct, simplejwt.A256GCM, key32, nil)
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	parts := strings.Split(zipped, ".")
+	parts[0] = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"dir","enc":"A256GCM","zip":"DEF"}`))
+	if _, _, err := simplejwt.Decrypt(strings.Join(parts, "."), simplejwt.Direct, simplejwt.A256GCM, key32); !errors.Is(err, simplejwt.ErrUnsupportedAlgorithm) {
+		t.Errorf("Expected ErrUnsupportedAlgorithm for a token with zip, got %v", err)
+	}
+}
+
+func TestEncryptedTokens(t *testing.T) {
+	recipient, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	issuer := simplejwt.New(
+		simplejwt.WithSecret(strings.Repeat("s", 32)),
+		simplejwt.WithEncryptionKey(simplejwt.RSAOAEP256, simplejwt.A256GCM, &recipient.PublicKey),
+	)
+	validator := simplejwt.New(
+		simplejwt.WithSecret(strings.Repeat("s", 32)),
+		simplejwt.WithDecryptionKey(simplejwt.RSAOAEP256, simplejwt.A256GCM, recipient),
+	)
+
+	payload := simplejwt.Payload{
+		Subject: "1234567890",
+		Expires: time.Now().Add(time.Hour),
+	}
+	if _, err := validator.GenerateEncrypted(payload, nil); !errors.Is(err, simplejwt.ErrNoEncryptionKey) {
+		t.Errorf("Expected ErrNoEncryptionKey, got %v", err)
+	}
+	token, err := issuer.GenerateEncrypted(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate encrypted token: %v", err)
+	}
+	validated, err := validator.ValidateEncrypted(token)
+	if err != nil {
+		t.Fatalf("Failed to validate encrypted token: %v", err)
+	}
+	if validated.Subject != payload.Subject {
+		t.Errorf("Expected subject %s, got %s", payload.Subject, validated.Subject)
+	}
+
+	// The signed token inside is validated as well
+	wrongSecret := simplejwt.New(
+		simplejwt.WithSecret(strings.Repeat("x", 32)),
+		simplejwt.WithDecryptionKey(simplejwt.RSAOAEP256, simplejwt.A256GCM, recipient),
+	)
+	if _, err := wrongSecret.ValidateEncrypted(token); !errors.Is(err, simplejwt.ErrInvalidTokenSignature) {
+		t.Errorf("Expected ErrInvalidTokenSignature, got %v", err)
+	}
+
+	// Encrypted tokens that are not nested JWT tokens are rejected
+	plain, err := simplejwt.Encrypt([]byte(`{"sub":"1234567890"}`), simplejwt.RSAOAEP256, simplejwt.A256GCM, &recipient.PublicKey, nil)
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	if _, err := validator.ValidateEncrypted(plain); !errors.Is(err, simplejwt.ErrInvalidTokenHeader) {
+		t.Errorf("Expected ErrInvalidTokenHeader, got %v", err)
+	}
+}
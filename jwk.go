@@ -0,0 +1,453 @@
+package simplejwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+)
+
+// JWK is a JSON Web Key, as described in RFC 7517.
+// Key is one of []byte (for "oct" keys), *rsa.PublicKey, *rsa.PrivateKey, *ecdsa.PublicKey,
+// *ecdsa.PrivateKey, ed25519.PublicKey or ed25519.PrivateKey (for "OKP" keys, as described in RFC 8037).
+type JWK struct {
+	Key       any
+	KeyID     string
+	Algorithm string
+	Use       string
+}
+
+// JWKSet is a JSON Web Key Set, as described in RFC 7517 section 5
+type JWKSet struct {
+	Keys []*JWK `json:"keys"`
+}
+
+// jwkJSON is the JSON representation of a JWK
+type jwkJSON struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	K         string `json:"k,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+	D         string `json:"d,omitempty"`
+	P         string `json:"p,omitempty"`
+	Q         string `json:"q,omitempty"`
+	DP        string `json:"dp,omitempty"`
+	DQ        string `json:"dq,omitempty"`
+	QI        string `json:"qi,omitempty"`
+}
+
+// curves maps the "crv" values of EC keys to elliptic curves
+var curves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// curveName returns the "crv" value for the given elliptic curve
+func curveName(curve elliptic.Curve) string {
+	for name, c := range curves {
+		if c == curve {
+			return name
+		}
+	}
+	return ""
+}
+
+// encodeBytes encodes bytes as unpadded base64url
+func encodeBytes(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// encodeInt encodes a big integer as unpadded base64url, using as few bytes as possible
+func encodeInt(i *big.Int) string {
+	return encodeBytes(i.Bytes())
+}
+
+// encodeFixed encodes a big integer as unpadded base64url, padded with zeros to the given size
+func encodeFixed(i *big.Int, size int) string {
+	return encodeBytes(i.FillBytes(make([]byte, size)))
+}
+
+// decodeInt decodes an unpadded base64url encoded big integer
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, ErrInvalidKey
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// Public returns a copy of the JWK that only contains the public key
+func (k *JWK) Public() *JWK {
+	public := *k
+	public.Key = publicKey(k.Key)
+	return &public
+}
+
+// IsPrivate checks if the JWK contains a private key, or a shared secret
+func (k *JWK) IsPrivate() bool {
+	switch k.Key.(type) {
+	case []byte, *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		return true
+	}
+	return false
+}
+
+// MarshalJSON encodes the JWK, including the private key parameters if Key is a private key
+func (k *JWK) MarshalJSON() ([]byte, error) {
+	encoded, err := k.encode()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encoded)
+}
+
+// encode returns the JSON representation of the JWK
+func (k *JWK) encode() (*jwkJSON, error) {
+	encoded := &jwkJSON{
+		KeyID:     k.KeyID,
+		Use:       k.Use,
+		Algorithm: k.Algorithm,
+	}
+	switch key := k.Key.(type) {
+	case []byte:
+		encoded.KeyType = "oct"
+		encoded.K = encodeBytes(key)
+	case *rsa.PrivateKey:
+		encoded.KeyType = "RSA"
+		encoded.N = encodeInt(key.N)
+		encoded.E = encodeInt(big.NewInt(int64(key.E)))
+		encoded.D = encodeInt(key.D)
+		// Keys with other than two primes are encoded with only the private exponent.
+		// The CRT values are computed here, instead of with Precompute, which would change the key.
+		if len(key.Primes) == 2 {
+			p, q := key.Primes[0], key.Primes[1]
+			one := big.NewInt(1)
+			encoded.P = encodeInt(p)
+			encoded.Q = encodeInt(q)
+			encoded.DP = encodeInt(new(big.Int).Mod(key.D, new(big.Int).Sub(p, one)))
+			encoded.DQ = encodeInt(new(big.Int).Mod(key.D, new(big.Int).Sub(q, one)))
+			encoded.QI = encodeInt(new(big.Int).ModInverse(q, p))
+		}
+	case *rsa.PublicKey:
+		encoded.KeyType = "RSA"
+		encoded.N = encodeInt(key.N)
+		encoded.E = encodeInt(big.NewInt(int64(key.E)))
+	case *ecdsa.PrivateKey:
+		public, err := (&JWK{Key: &key.PublicKey}).encode()
+		if err != nil {
+			return nil, err
+		}
+		encoded.KeyType, encoded.Curve, encoded.X, encoded.Y = public.KeyType, public.Curve, public.X, public.Y
+		encoded.D = encodeFixed(key.D, (key.Curve.Params().BitSize+7)/8)
+	case *ecdsa.PublicKey:
+		encoded.KeyType = "EC"
+		encoded.Curve = curveName(key.Curve)
+		if encoded.Curve == "" {
+			return nil, ErrUnsupportedKeyType
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		encoded.X = encodeFixed(key.X, size)
+		encoded.Y = encodeFixed(key.Y, size)
+	case ed25519.PrivateKey:
+		encoded.KeyType = "OKP"
+		encoded.Curve = "Ed25519"
+		encoded.X = encodeBytes(key.Public().(ed25519.PublicKey))
+		encoded.D = encodeBytes(key.Seed())
+	case ed25519.PublicKey:
+		encoded.KeyType = "OKP"
+		encoded.Curve = "Ed25519"
+		encoded.X = encodeBytes(key)
+	default:
+		return nil, ErrUnsupportedKeyType
+	}
+	return encoded, nil
+}
+
+// UnmarshalJSON decodes a JWK of type "oct", "RSA", "EC" or "OKP"
+func (k *JWK) UnmarshalJSON(data []byte) error {
+	var encoded jwkJSON
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	key, err := encoded.decodeKey()
+	if err != nil {
+		return err
+	}
+	*k = JWK{
+		Key:       key,
+		KeyID:     encoded.KeyID,
+		Algorithm: encoded.Algorithm,
+		Use:       encoded.Use,
+	}
+	return nil
+}
+
+// decodeKey returns the key that the JSON representation describes
+func (encoded *jwkJSON) decodeKey() (any, error) {
+	switch encoded.KeyType {
+	case "oct":
+		k, err := base64.RawURLEncoding.DecodeString(encoded.K)
+		if err != nil || len(k) == 0 {
+			return nil, ErrInvalidKey
+		}
+		return k, nil
+	case "RSA":
+		return encoded.decodeRSA()
+	case "EC":
+		return encoded.decodeEC()
+	case "OKP":
+		return encoded.decodeOKP()
+	}
+	return nil, ErrUnsupportedKeyType
+}
+
+func (encoded *jwkJSON) decodeRSA() (any, error) {
+	n, err := decodeInt(encoded.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeInt(encoded.E)
+	if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, ErrInvalidKey
+	}
+	public := rsa.PublicKey{N: n, E: int(e.Int64())}
+	if encoded.D == "" {
+		return &public, nil
+	}
+	d, err := decodeInt(encoded.D)
+	if err != nil {
+		return nil, err
+	}
+	privateKey := &rsa.PrivateKey{PublicKey: public, D: d}
+	if encoded.P == "" && encoded.Q == "" {
+		// The primes are optional, as described in RFC 7518 section 6.3.2, and without them
+		// the key is only checked by encrypting and decrypting a small number with it
+		m := big.NewInt(2)
+		c := new(big.Int).Exp(m, big.NewInt(int64(public.E)), n)
+		if n.Sign() <= 0 || d.Sign() <= 0 || new(big.Int).Exp(c, d, n).Cmp(m) != 0 {
+			return nil, ErrInvalidKey
+		}
+		return privateKey, nil
+	}
+	if encoded.P == "" || encoded.Q == "" {
+		return nil, ErrInvalidKey
+	}
+	p, err := decodeInt(encoded.P)
+	if err != nil {
+		return nil, err
+	}
+	q, err := decodeInt(encoded.Q)
+	if err != nil {
+		return nil, err
+	}
+	privateKey.Primes = []*big.Int{p, q}
+	if err := privateKey.Validate(); err != nil {
+		return nil, ErrInvalidKey
+	}
+	privateKey.Precompute()
+	return privateKey, nil
+}
+
+func (encoded *jwkJSON) decodeEC() (any, error) {
+	curve, ok := curves[encoded.Curve]
+	if !ok {
+		return nil, ErrUnsupportedKeyType
+	}
+	x, err := decodeInt(encoded.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeInt(encoded.Y)
+	if err != nil {
+		return nil, err
+	}
+	public := ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	if _, err := public.ECDH(); err != nil {
+		return nil, ErrInvalidKey
+	}
+	if encoded.D == "" {
+		return &public, nil
+	}
+	d, err := decodeInt(encoded.D)
+	if err != nil {
+		return nil, err
+	}
+	privateKey := &ecdsa.PrivateKey{PublicKey: public, D: d}
+	ecdhPrivateKey, err := privateKey.ECDH()
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	if ecdhPublicKey, _ := public.ECDH(); !ecdhPrivateKey.PublicKey().Equal(ecdhPublicKey) {
+		return nil, ErrInvalidKey
+	}
+	return privateKey, nil
+}
+
+func (encoded *jwkJSON) decodeOKP() (any, error) {
+	if encoded.Curve != "Ed25519" {
+		return nil, ErrUnsupportedKeyType
+	}
+	x, err := base64.RawURLEncoding.DecodeString(encoded.X)
+	if err != nil || len(x) != ed25519.PublicKeySize {
+		return nil, ErrInvalidKey
+	}
+	if encoded.D == "" {
+		return ed25519.PublicKey(x), nil
+	}
+	d, err := base64.RawURLEncoding.DecodeString(encoded.D)
+	if err != nil || len(d) != ed25519.SeedSize {
+		return nil, ErrInvalidKey
+	}
+	privateKey := ed25519.NewKeyFromSeed(d)
+	if !privateKey.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(x)) {
+		return nil, ErrInvalidKey
+	}
+	return privateKey, nil
+}
+
+// Thumbprint returns the JWK thumbprint, as described in RFC 7638, using the given hash function.
+// The thumbprint of a private key is the same as the thumbprint of its public key.
+func (k *JWK) Thumbprint(hash crypto.Hash) ([]byte, error) {
+	encoded, err := k.Public().encode()
+	if err != nil {
+		return nil, err
+	}
+	// The required members, in lexicographic order
+	var members any
+	switch encoded.KeyType {
+	case "oct":
+		members = struct {
+			K       string `json:"k"`
+			KeyType string `json:"kty"`
+		}{encoded.K, encoded.KeyType}
+	case "RSA":
+		members = struct {
+			E       string `json:"e"`
+			KeyType string `json:"kty"`
+			N       string `json:"n"`
+		}{encoded.E, encoded.KeyType, encoded.N}
+	case "EC":
+		members = struct {
+			Curve   string `json:"crv"`
+			KeyType string `json:"kty"`
+			X       string `json:"x"`
+			Y       string `json:"y"`
+		}{encoded.Curve, encoded.KeyType, encoded.X, encoded.Y}
+	case "OKP":
+		members = struct {
+			Curve   string `json:"crv"`
+			KeyType string `json:"kty"`
+			X       string `json:"x"`
+		}{encoded.Curve, encoded.KeyType, encoded.X}
+	}
+	data, err := json.Marshal(members)
+	if err != nil {
+		return nil, err
+	}
+	return digest(hash, data), nil
+}
+
+// ThumbprintKeyID returns the base64url encoded SHA-256 thumbprint of the JWK,
+// which is suitable as a "kid" value
+func (k *JWK) ThumbprintKeyID() (string, error) {
+	thumbprint, err := k.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+	return encodeBytes(thumbprint), nil
+}
+
+// ParseJWKSet parses a JSON Web Key Set. Keys of unsupported types are skipped,
+// as recommended by RFC 7517 section 5.
+func ParseJWKSet(data []byte) (*JWKSet, error) {
+	var encoded struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, err
+	}
+	set := &JWKSet{}
+	for _, data := range encoded.Keys {
+		var key JWK
+		if err := json.Unmarshal(data, &key); err != nil {
+			continue
+		}
+		set.Keys = append(set.Keys, &key)
+	}
+	return set, nil
+}
+
+// Public returns a copy of the JWK Set that only contains public keys, for publishing.
+// Shared secrets are left out.
+func (s *JWKSet) Public() *JWKSet {
+	public := &JWKSet{}
+	for _, key := range s.Keys {
+		if _, ok := key.Key.([]byte); ok {
+			continue
+		}
+		public.Keys = append(public.Keys, key.Public())
+	}
+	return public
+}
+
+// Key returns the key with the given key ID, or nil if there is no such key
+func (s *JWKSet) Key(keyID string) *JWK {
+	for _, key := range s.Keys {
+		if key.KeyID == keyID {
+			return key
+		}
+	}
+	return nil
+}
+
+// LookupKey returns the key that matches the "kid" header of a token.
+// If the token has no "kid" header, and the set only has one key, that key is returned.
+func (s *JWKSet) LookupKey(header *Header) (*JWK, error) {
+	if header.KeyID == "" && len(s.Keys) == 1 {
+		return s.Keys[0], nil
+	}
+	if header.KeyID != "" {
+		if key := s.Key(header.KeyID); key != nil {
+			return key, nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
+
+// algorithm returns the "alg" of the JWK, or the default algorithm for the key type
+func (k *JWK) algorithm() string {
+	if k.Algorithm != "" {
+		return k.Algorithm
+	}
+	return defaultAlgorithm(k.Key)
+}
+
+// defaultAlgorithm returns the algorithm that is used for a key when no algorithm is given
+func defaultAlgorithm(key any) string {
+	switch k := publicKey(key).(type) {
+	case *rsa.PublicKey:
+		return RS256
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P384():
+			return ES384
+		case elliptic.P521():
+			return ES512
+		}
+		return ES256
+	case ed25519.PublicKey:
+		return EdDSA
+	}
+	return HS256
+}
@@ -0,0 +1,170 @@
+package simplejwt_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xyproto/simplejwt"
+)
+
+func TestJWKRoundTrip(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	payload := simplejwt.Payload{
+		Subject: "1234567890",
+		Expires: time.Now().Add(time.Hour),
+	}
+
+	// Keys without primes, and keys with more than two primes, which are encoded without them
+	exponentOnly := &rsa.PrivateKey{PublicKey: rsaKey.PublicKey, D: rsaKey.D}
+	multiPrimeKey, err := rsa.GenerateMultiPrimeKey(rand.Reader, 3, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate multi-prime RSA key: %v", err)
+	}
+
+	for _, key := range []any{[]byte("a-shared-secret-that-is-long-enough"), rsaKey, exponentOnly, multiPrimeKey, ecKey, edKey} {
+		jwk := &simplejwt.JWK{Key: key}
+		kid, err := jwk.ThumbprintKeyID()
+		if err != nil {
+			t.Fatalf("Failed to compute thumbprint for %T: %v", key, err)
+		}
+		jwk.KeyID = kid
+
+		data, err := json.Marshal(jwk)
+		if err != nil {
+			t.Fatalf("Failed to marshal %T: %v", key, err)
+		}
+
+		var decoded simplejwt.JWK
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Failed to unmarshal %s: %v", data, err)
+		}
+		if decoded.KeyID != kid {
+			t.Errorf("Expected kid %s, got %s", kid, decoded.KeyID)
+		}
+
+		// Sign with the decoded private key, validate with the public key from a JWK Set
+		token, err := simplejwt.New(simplejwt.WithJWK(&decoded)).Generate(payload, nil)
+		if err != nil {
+			t.Fatalf("Failed to generate token with %T: %v", key, err)
+		}
+
+		set := &simplejwt.JWKSet{Keys: []*simplejwt.JWK{jwk}}
+		if _, ok := key.([]byte); !ok {
+			set = set.Public()
+		}
+		data, err = json.Marshal(set)
+		if err != nil {
+			t.Fatalf("Failed to marshal JWK Set: %v", err)
+		}
+		parsed, err := simplejwt.ParseJWKSet(data)
+		if err != nil {
+			t.Fatalf("Failed to parse JWK Set: %v", err)
+		}
+		public := parsed.Key(kid)
+		if public == nil {
+			t.Fatalf("Expected to find key %s in %s", kid, data)
+		}
+		if _, err := simplejwt.New(simplejwt.WithJWK(public)).Validate(token); err != nil {
+			t.Errorf("Failed to validate token with %T: %v", key, err)
+		}
+
+		publicKID, err := public.ThumbprintKeyID()
+		if err != nil || publicKID != kid {
+			t.Errorf("Expected the public key to have the same thumbprint %s, got %s", kid, publicKID)
+		}
+	}
+}
+
+func TestJWKDoesNotChangeKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	key := &rsa.PrivateKey{PublicKey: rsaKey.PublicKey, D: rsaKey.D, Primes: rsaKey.Primes}
+	data, err := json.Marshal(&simplejwt.JWK{Key: key})
+	if err != nil {
+		t.Fatalf("Failed to marshal RSA key: %v", err)
+	}
+	if key.Precomputed.Dp != nil {
+		t.Errorf("Expected the key to be left without precomputed values")
+	}
+
+	var decoded simplejwt.JWK
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal %s: %v", data, err)
+	}
+	if !rsaKey.Equal(decoded.Key) {
+		t.Errorf("Expected the decoded key to be the same as the original key")
+	}
+
+	// A private exponent that does not match the public key is rejected
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Failed to decode %s: %v", data, err)
+	}
+	for _, name := range []string{"p", "q", "dp", "dq", "qi"} {
+		delete(fields, name)
+	}
+	fields["d"] = fields["n"]
+	data, _ = json.Marshal(fields)
+	if err := json.Unmarshal(data, &decoded); !errors.Is(err, simplejwt.ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey for a wrong private exponent, got %v", err)
+	}
+}
+
+func TestJWKThumbprint(t *testing.T) {
+	// The example from RFC 7638 section 3.1
+	data := []byte(`{
+		"kty": "RSA",
+		"n": "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		"e": "AQAB",
+		"alg": "RS256",
+		"kid": "2011-04-29"
+	}`)
+
+	var jwk simplejwt.JWK
+	if err := json.Unmarshal(data, &jwk); err != nil {
+		t.Fatalf("Failed to unmarshal JWK: %v", err)
+	}
+
+	kid, err := jwk.ThumbprintKeyID()
+	if err != nil {
+		t.Fatalf("Failed to compute thumbprint: %v", err)
+	}
+	if expected := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; kid != expected {
+		t.Errorf("Expected thumbprint %s, got %s", expected, kid)
+	}
+
+	if _, err := jwk.Thumbprint(crypto.SHA512); err != nil {
+		t.Errorf("Failed to compute SHA-512 thumbprint: %v", err)
+	}
+
+	// Keys of unknown types are skipped when parsing a JWK Set
+	set, err := simplejwt.ParseJWKSet([]byte(`{"keys":[{"kty":"unknown"},` + string(data) + `]}`))
+	if err != nil {
+		t.Fatalf("Failed to parse JWK Set: %v", err)
+	}
+	if len(set.Keys) != 1 || set.Key("2011-04-29") == nil {
+		t.Errorf("Expected one key in the JWK Set, got %d", len(set.Keys))
+	}
+}
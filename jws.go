@@ -0,0 +1,172 @@
+package simplejwt
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// jwsJSON is a token in the general or flattened JWS JSON serialization from RFC 7515
+type jwsJSON struct {
+	Payload    string         `json:"payload"`
+	Signatures []jwsSignature `json:"signatures,omitempty"`
+
+	// The flattened serialization has a single signature next to the payload
+	jwsSignature
+}
+
+// jwsSignature is one signature of a token in JWS JSON serialization
+type jwsSignature struct {
+	Protected string          `json:"protected,omitempty"`
+	Header    json.RawMessage `json:"header,omitempty"`
+	Signature string          `json:"signature,omitempty"`
+}
+
+// GenerateJSON generates a token in the general JWS JSON serialization, with one signature
+// from each of the given instances over the same claims. This makes it possible to hand
+// one token to consumers that trust different keys.
+func GenerateJSON(claims any, signers ...*Instance) ([]byte, error) {
+	if len(signers) == 0 {
+		return nil, ErrNoSigners
+	}
+	payloadBytes, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	var token jwsJSON
+	for i, signer := range signers {
+		if i == 0 {
+			token.Payload = signer.payloadSegment(payloadBytes)
+		} else if signer.unencoded != signers[0].unencoded {
+			return nil, ErrMixedPayloadEncoding
+		}
+		headerEncoded, signature, err := signer.sign(token.Payload, nil)
+		if err != nil {
+			return nil, err
+		}
+		token.Signatures = append(token.Signatures, jwsSignature{Protected: headerEncoded, Signature: signature})
+	}
+
+	return json.Marshal(token)
+}
+
+// GenerateFlattenedJSON generates a token with arbitrary claims and an optional custom header,
+// like GenerateClaims, but in the flattened JWS JSON serialization
+func (inst *Instance) GenerateFlattenedJSON(claims any, customHeader *Header) ([]byte, error) {
+	payloadBytes, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	token := jwsJSON{Payload: inst.payloadSegment(payloadBytes)}
+	token.Protected, token.Signature, err = inst.sign(token.Payload, customHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(token)
+}
+
+// ValidateJSON validates a token in the general or flattened JWS JSON serialization, and decodes
+// the claims into the value pointed to by claims, like ValidateInto. The token is valid if any
+// of its signatures can be verified with the keys of the instance.
+// If the token is not valid, the returned error is a *ValidationError.
+func (inst *Instance) ValidateJSON(data []byte, claims any) error {
+	header, payloadBytes, err := inst.verifyJSON(data)
+	if err != nil {
+		return validationError(err)
+	}
+
+	return inst.validatePayload(&header, payloadBytes, claims)
+}
+
+// verifyJSON checks the signatures of a token in JWS JSON serialization, and returns the
+// header of the first valid signature and the decoded payload, if at least one of them is valid
+func (inst *Instance) verifyJSON(data []byte) (Header, []byte, error) {
+	var token jwsJSON
+	if err := json.Unmarshal(data, &token); err != nil {
+		return Header{}, nil, ErrInvalidTokenFormat
+	}
+
+	signatures := token.Signatures
+	if token.Signature != "" {
+		if len(signatures) > 0 {
+			return Header{}, nil, ErrInvalidTokenFormat
+		}
+		signatures = []jwsSignature{token.jwsSignature}
+	}
+	if len(signatures) == 0 {
+		return Header{}, nil, ErrInvalidTokenFormat
+	}
+
+	var firstErr error
+	for _, signature := range signatures {
+		header, err := inst.verifyJSONSignature(token.Payload, signature)
+		if err == nil {
+			payloadBytes, err := inst.decodePayload(&header, token.Payload)
+			if err != nil {
+				return Header{}, nil, ErrInvalidTokenPayload
+			}
+			return header, payloadBytes, nil
+		}
+		// Signatures made with other algorithms, keys of other types or unknown keys are expected,
+		// so any other error is more useful to return
+		if firstErr == nil || errors.Is(firstErr, ErrKeyTypeMismatch) || errors.Is(firstErr, ErrKeyNotFound) || errors.Is(firstErr, ErrAlgorithmNotAllowed) {
+			firstErr = err
+		}
+	}
+	return Header{}, nil, firstErr
+}
+
+// protectedOnly are the header parameters that are only accepted in the protected header,
+// since they change how the token is validated
+var protectedOnly = map[string]bool{"b64": true, "crit": true, "typ": true, "cty": true}
+
+// verifyJSONSignature checks one signature of a token in JWS JSON serialization, and returns its header.
+// The header is the union of the protected and the unprotected header, which can not
+// have any parameters in common. The "b64", "crit", "typ" and "cty" parameters must be protected.
+func (inst *Instance) verifyJSONSignature(payloadEncoded string, signature jwsSignature) (Header, error) {
+	protectedBytes, err := inst.decodeSegment(signature.Protected)
+	if err != nil {
+		return Header{}, ErrInvalidTokenHeader
+	}
+
+	params := make(map[string]json.RawMessage)
+	if len(protectedBytes) > 0 {
+		if err := json.Unmarshal(protectedBytes, &params); err != nil {
+			return Header{}, ErrInvalidTokenHeader
+		}
+	}
+	if len(signature.Header) > 0 {
+		var unprotected map[string]json.RawMessage
+		if err := json.Unmarshal(signature.Header, &unprotected); err != nil {
+			return Header{}, ErrInvalidTokenHeader
+		}
+		for name, value := range unprotected {
+			if _, ok := params[name]; ok || protectedOnly[name] {
+				return Header{}, ErrInvalidTokenHeader
+			}
+			params[name] = value
+		}
+	}
+
+	headerBytes, err := json.Marshal(params)
+	if err != nil {
+		return Header{}, ErrInvalidTokenHeader
+	}
+	var header Header
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return Header{}, ErrInvalidTokenHeader
+	}
+
+	signatureBytes, err := inst.decodeSegment(signature.Signature)
+	if err != nil {
+		return Header{}, ErrInvalidTokenSignature
+	}
+
+	if err := inst.verifySignature(&header, signature.Protected+"."+payloadEncoded, signatureBytes); err != nil {
+		return Header{}, err
+	}
+
+	return header, nil
+}
@@ -0,0 +1,145 @@
+package simplejwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xyproto/simplejwt"
+)
+
+func TestJSONSerialization(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	rsaSigner := simplejwt.New(simplejwt.WithSigningKey(simplejwt.RS256, rsaKey))
+	ecSigner := simplejwt.New(simplejwt.WithSigningKey(simplejwt.ES256, ecKey))
+
+	payload := simplejwt.Payload{
+		Subject: "1234567890",
+		Expires: time.Now().Add(time.Hour),
+	}
+	token, err := simplejwt.GenerateJSON(payload, rsaSigner, ecSigner)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	// At least one signer is needed, and all of them must encode the payload the same way
+	if _, err := simplejwt.GenerateJSON(payload); !errors.Is(err, simplejwt.ErrNoSigners) {
+		t.Errorf("Expected ErrNoSigners, got %v", err)
+	}
+	unencodedSigner := simplejwt.New(simplejwt.WithSigningKey(simplejwt.ES256, ecKey), simplejwt.WithUnencodedPayload())
+	if _, err := simplejwt.GenerateJSON(payload, rsaSigner, unencodedSigner); !errors.Is(err, simplejwt.ErrMixedPayloadEncoding) {
+		t.Errorf("Expected ErrMixedPayloadEncoding, got %v", err)
+	}
+
+	var general struct {
+		Payload    string            `json:"payload"`
+		Signatures []json.RawMessage `json:"signatures"`
+	}
+	if err := json.Unmarshal(token, &general); err != nil {
+		t.Fatalf("Failed to decode token: %v", err)
+	}
+	if general.Payload == "" || len(general.Signatures) != 2 {
+		t.Fatalf("Expected a payload and 2 signatures, got %s", token)
+	}
+
+	// Consumers that trust either of the keys can validate the token
+	for _, consumer := range []*simplejwt.Instance{
+		simplejwt.New(simplejwt.WithVerificationKey(&rsaKey.PublicKey)),
+		simplejwt.New(simplejwt.WithVerificationKey(&ecKey.PublicKey)),
+	} {
+		var validated simplejwt.Payload
+		if err := consumer.ValidateJSON(token, &validated); err != nil {
+			t.Fatalf("Failed to validate token: %v", err)
+		}
+		if validated.Subject != payload.Subject {
+			t.Errorf("Expected subject %s, got %s", payload.Subject, validated.Subject)
+		}
+	}
+
+	// Consumers that trust neither of the keys can not
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	stranger := simplejwt.New(simplejwt.WithVerificationKey(&otherKey.PublicKey))
+	var validated simplejwt.Payload
+	if err := stranger.ValidateJSON(token, &validated); err == nil {
+		t.Error("Expected an error for a token without a trusted signature")
+	}
+
+	// Changing the payload invalidates all signatures
+	forgedPayload, _ := json.Marshal(simplejwt.Payload{Subject: "admin", Expires: payload.Expires})
+	forged := strings.Replace(string(token), general.Payload, base64.RawURLEncoding.EncodeToString(forgedPayload), 1)
+	ecConsumer := simplejwt.New(simplejwt.WithVerificationKey(&ecKey.PublicKey))
+	if err := ecConsumer.ValidateJSON([]byte(forged), &validated); !errors.Is(err, simplejwt.ErrInvalidTokenSignature) {
+		t.Errorf("Expected ErrInvalidTokenSignature for a modified payload, got %v", err)
+	}
+
+	// The flattened serialization has one signature next to the payload
+	flattened, err := ecSigner.GenerateFlattenedJSON(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate flattened token: %v", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(flattened, &fields); err != nil {
+		t.Fatalf("Failed to decode flattened token: %v", err)
+	}
+	for _, name := range []string{"payload", "protected", "signature"} {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("Expected %q in the flattened token, got %s", name, flattened)
+		}
+	}
+	if err := ecConsumer.ValidateJSON(flattened, &validated); err != nil {
+		t.Fatalf("Failed to validate flattened token: %v", err)
+	}
+
+	// Parameters can be in the unprotected header, but not in both headers
+	jwk := &simplejwt.JWK{Key: &ecKey.PublicKey, KeyID: "ec-key"}
+	kidConsumer := simplejwt.New(simplejwt.WithKeySource(&simplejwt.JWKSet{Keys: []*simplejwt.JWK{jwk}}))
+	var flat map[string]any
+	json.Unmarshal(flattened, &flat)
+	flat["header"] = map[string]string{"kid": "ec-key"}
+	withKeyID, _ := json.Marshal(flat)
+	if err := kidConsumer.ValidateJSON(withKeyID, &validated); err != nil {
+		t.Errorf("Failed to validate token with an unprotected header: %v", err)
+	}
+	flat["header"] = map[string]string{"alg": simplejwt.ES256}
+	duplicate, _ := json.Marshal(flat)
+	if err := ecConsumer.ValidateJSON(duplicate, &validated); !errors.Is(err, simplejwt.ErrInvalidTokenHeader) {
+		t.Errorf("Expected ErrInvalidTokenHeader for a duplicate header parameter, got %v", err)
+	}
+
+	// The type can not be set in the unprotected header, where it is not signed
+	typed := simplejwt.New(simplejwt.WithVerificationKey(&ecKey.PublicKey), simplejwt.WithRequiredType("at+jwt"))
+	untyped, err := simplejwt.New(simplejwt.WithSigningKey(simplejwt.ES256, ecKey)).GenerateFlattenedJSON(payload, &simplejwt.Header{})
+	if err != nil {
+		t.Fatalf("Failed to generate flattened token: %v", err)
+	}
+	json.Unmarshal(untyped, &flat)
+	for _, name := range []string{"typ", "cty"} {
+		flat["header"] = map[string]string{name: "at+jwt"}
+		unsigned, _ := json.Marshal(flat)
+		if err := typed.ValidateJSON(unsigned, &validated); !errors.Is(err, simplejwt.ErrInvalidTokenHeader) {
+			t.Errorf("Expected ErrInvalidTokenHeader for %s in the unprotected header, got %v", name, err)
+		}
+	}
+	delete(flat, "header")
+	withoutType, _ := json.Marshal(flat)
+	if err := typed.ValidateJSON(withoutType, &validated); !errors.Is(err, simplejwt.ErrInvalidTokenType) {
+		t.Errorf("Expected ErrInvalidTokenType for a token without typ, got %v", err)
+	}
+}
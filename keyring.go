@@ -0,0 +1,140 @@
+package simplejwt
+
+import (
+	"sync"
+	"time"
+)
+
+// KeySource looks up the key that should be used for validating a token,
+// typically by the "kid" header of the token
+type KeySource interface {
+	LookupKey(header *Header) (*JWK, error)
+}
+
+// keyringEntry is a key in a keyring, together with when it is used for signing
+type keyringEntry struct {
+	key       *JWK
+	activates time.Time
+	retires   time.Time
+}
+
+// Keyring holds several keys, so that keys can be rotated without invalidating
+// outstanding tokens. New tokens are signed with the active key, and tokens are
+// validated with whichever key in the keyring matches their "kid" header,
+// including keys that are retired or not yet active.
+type Keyring struct {
+	mut     sync.RWMutex
+	entries []keyringEntry
+	clock   Clock
+}
+
+// NewKeyring creates a new, empty keyring. The clock decides which key is active,
+// and can be nil to use the system clock.
+func NewKeyring(clock Clock) *Keyring {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &Keyring{clock: clock}
+}
+
+// Add adds a key to the keyring. The key is used for signing new tokens from the activation time
+// until the retirement time, and for validating tokens until it is removed.
+// A zero activation time means that the key is active right away, and a zero retirement
+// time means that the key is never retired. If the key has no key ID, its JWK
+// thumbprint is used as the key ID.
+func (kr *Keyring) Add(key *JWK, activates, retires time.Time) error {
+	if key.KeyID == "" {
+		keyID, err := key.ThumbprintKeyID()
+		if err != nil {
+			return err
+		}
+		withID := *key
+		withID.KeyID = keyID
+		key = &withID
+	}
+	kr.mut.Lock()
+	defer kr.mut.Unlock()
+	kr.entries = append(kr.entries, keyringEntry{key, activates, retires})
+	return nil
+}
+
+// Remove removes the key with the given key ID from the keyring,
+// so that tokens signed with it are no longer valid
+func (kr *Keyring) Remove(keyID string) {
+	kr.mut.Lock()
+	defer kr.mut.Unlock()
+	entries := kr.entries[:0]
+	for _, entry := range kr.entries {
+		if entry.key.KeyID != keyID {
+			entries = append(entries, entry)
+		}
+	}
+	kr.entries = entries
+}
+
+// Prune removes the keys that were retired longer ago than the given duration,
+// which should be longer than the lifetime of the tokens signed with them
+func (kr *Keyring) Prune(retiredFor time.Duration) {
+	cutoff := kr.clock.Now().Add(-retiredFor)
+	kr.mut.Lock()
+	defer kr.mut.Unlock()
+	entries := kr.entries[:0]
+	for _, entry := range kr.entries {
+		if entry.retires.IsZero() || entry.retires.After(cutoff) {
+			entries = append(entries, entry)
+		}
+	}
+	kr.entries = entries
+}
+
+// ActiveKey returns the key that new tokens should be signed with.
+// If several keys are active, the most recently activated key is returned.
+func (kr *Keyring) ActiveKey() (*JWK, error) {
+	now := kr.clock.Now()
+	kr.mut.RLock()
+	defer kr.mut.RUnlock()
+	var active *keyringEntry
+	for i, entry := range kr.entries {
+		if !entry.key.IsPrivate() || now.Before(entry.activates) {
+			continue
+		}
+		if !entry.retires.IsZero() && !now.Before(entry.retires) {
+			continue
+		}
+		if active == nil || !entry.activates.Before(active.activates) {
+			active = &kr.entries[i]
+		}
+	}
+	if active == nil {
+		return nil, ErrNoActiveKey
+	}
+	return active.key, nil
+}
+
+// LookupKey returns the key that matches the "kid" header of a token.
+// Tokens without a "kid" header are validated with the active key.
+func (kr *Keyring) LookupKey(header *Header) (*JWK, error) {
+	if header.KeyID == "" {
+		return kr.ActiveKey()
+	}
+	kr.mut.RLock()
+	defer kr.mut.RUnlock()
+	for _, entry := range kr.entries {
+		if entry.key.KeyID == header.KeyID {
+			return entry.key, nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
+
+// JWKSet returns the public keys of the keyring as a JWK Set, for publishing.
+// Shared secrets are left out.
+func (kr *Keyring) JWKSet() *JWKSet {
+	kr.mut.RLock()
+	defer kr.mut.RUnlock()
+	set := &JWKSet{}
+	for _, entry := range kr.entries {
+		set.Keys = append(set.Keys, entry.key)
+	}
+	return set.Public()
+}
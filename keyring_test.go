@@ -0,0 +1,71 @@
+package simplejwt_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xyproto/simplejwt"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	clock := simplejwt.ClockFunc(func() time.Time { return now })
+
+	keyring := simplejwt.NewKeyring(clock)
+	oldKey := &simplejwt.JWK{Key: []byte(strings.Repeat("o", 32)), KeyID: "old"}
+	newKey := &simplejwt.JWK{Key: []byte(strings.Repeat("n", 32)), KeyID: "new"}
+
+	// The old key is retired when the new key is activated, in one hour
+	rotation := now.Add(time.Hour)
+	if err := keyring.Add(oldKey, time.Time{}, rotation); err != nil {
+		t.Fatalf("Failed to add key: %v", err)
+	}
+	if err := keyring.Add(newKey, rotation, time.Time{}); err != nil {
+		t.Fatalf("Failed to add key: %v", err)
+	}
+
+	jwt := simplejwt.New(simplejwt.WithKeyring(keyring), simplejwt.WithClock(clock))
+	payload := simplejwt.Payload{Subject: "alice", Expires: now.Add(2 * time.Hour)}
+
+	oldToken, err := jwt.Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if header := mustDecodeSegment(t, oldToken, 0); !strings.Contains(header, `"kid":"old"`) {
+		t.Errorf("Expected the token to be signed with the old key, got header %s", header)
+	}
+
+	// After the rotation, new tokens use the new key, and old tokens are still valid
+	now = rotation.Add(time.Minute)
+	newToken, err := jwt.Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if header := mustDecodeSegment(t, newToken, 0); !strings.Contains(header, `"kid":"new"`) {
+		t.Errorf("Expected the token to be signed with the new key, got header %s", header)
+	}
+	for _, token := range []string{oldToken, newToken} {
+		if _, err := jwt.Validate(token); err != nil {
+			t.Errorf("Failed to validate token: %v", err)
+		}
+	}
+
+	// Once the old key has been retired for long enough, it is pruned
+	now = rotation.Add(3 * time.Hour)
+	payload.Expires = now.Add(time.Hour)
+	keyring.Prune(2 * time.Hour)
+	if _, err := keyring.LookupKey(&simplejwt.Header{KeyID: "old"}); !errors.Is(err, simplejwt.ErrKeyNotFound) {
+		t.Errorf("Expected the old key to be pruned, got %v", err)
+	}
+
+	// A token with an unknown kid is rejected
+	forged, err := simplejwt.New(simplejwt.WithSecret(strings.Repeat("o", 32))).Generate(payload, &simplejwt.Header{KeyID: "unknown", Type: "JWT"})
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := jwt.Validate(forged); !errors.Is(err, simplejwt.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+package simplejwt
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// OIDCProvider is an OpenID Connect provider, as described by its discovery document
+type OIDCProvider struct {
+	Issuer                   string   `json:"issuer"`
+	AuthorizationEndpoint    string   `json:"authorization_endpoint"`
+	TokenEndpoint            string   `json:"token_endpoint"`
+	UserInfoEndpoint         string   `json:"userinfo_endpoint"`
+	JWKSURI                  string   `json:"jwks_uri"`
+	IDTokenSigningAlgorithms []string `json:"id_token_signing_alg_values_supported"`
+
+	// Keys is the JWK Set of the provider, fetched from JWKSURI
+	Keys *RemoteKeySet `json:"-"`
+}
+
+// DiscoverOIDC reads the discovery document at /.well-known/openid-configuration
+// for the given issuer, and sets up a RemoteKeySet for the JWKS URL of the provider.
+// The options are used for the RemoteKeySet, and the HTTP client is also used for discovery.
+func DiscoverOIDC(ctx context.Context, issuer string, options ...RemoteOption) (*OIDCProvider, error) {
+	keys := NewRemoteKeySet("", options...)
+
+	url := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := keys.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	var provider OIDCProvider
+	if err := json.Unmarshal(data, &provider); err != nil {
+		return nil, err
+	}
+	if provider.Issuer != issuer {
+		return nil, fmt.Errorf("discovery document is for issuer %q, not %q", provider.Issuer, issuer)
+	}
+	if provider.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document for %q has no jwks_uri", issuer)
+	}
+
+	keys.url = provider.JWKSURI
+	provider.Keys = keys
+	return &provider, nil
+}
+
+// Endpoint returns the OAuth 2.0 endpoints of the provider, for use with golang.org/x/oauth2
+func (p *OIDCProvider) Endpoint() oauth2.Endpoint {
+	return oauth2.Endpoint{
+		AuthURL:  p.AuthorizationEndpoint,
+		TokenURL: p.TokenEndpoint,
+	}
+}
+
+// IDToken holds the claims of a validated OpenID Connect ID token
+type IDToken struct {
+	Issuer          string       `json:"iss"`
+	Subject         string       `json:"sub"`
+	Audience        Audience     `json:"aud"`
+	Expires         *NumericDate `json:"exp"`
+	IssuedAt        *NumericDate `json:"iat,omitempty"`
+	AuthTime        *NumericDate `json:"auth_time,omitempty"`
+	Nonce           string       `json:"nonce,omitempty"`
+	AuthorizedParty string       `json:"azp,omitempty"`
+	AccessTokenHash string       `json:"at_hash,omitempty"`
+
+	// Claims holds all the claims of the ID token, including the ones above
+	Claims Claims `json:"-"`
+
+	algorithm string
+}
+
+// IDTokenVerifier validates ID tokens issued by an OIDCProvider for one client
+type IDTokenVerifier struct {
+	// MaxAge is the max_age that was requested when authenticating.
+	// If it is not zero, the "auth_time" claim is required and can not be older than this.
+	MaxAge time.Duration
+
+	clientID string
+	inst     *Instance
+}
+
+// Verifier returns an IDTokenVerifier for ID tokens issued to the given client ID.
+// The "iss" claim must be the issuer of the provider and the "aud" claim must contain
+// the client ID. Only the signing algorithms advertised by the provider are accepted,
+// or RS256 if it advertises none. More options, such as WithLeeway, can be given.
+func (p *OIDCProvider) Verifier(clientID string, options ...Option) *IDTokenVerifier {
+	algorithms := []string{RS256}
+	if len(p.IDTokenSigningAlgorithms) > 0 {
+		algorithms = p.IDTokenSigningAlgorithms
+	}
+	options = append([]Option{
+		WithKeySource(p.Keys),
+		WithAllowedAlgorithms(algorithms...),
+		WithRequiredIssuer(p.Issuer),
+		WithRequiredAudience(clientID),
+	}, options...)
+	return &IDTokenVerifier{
+		clientID: clientID,
+		inst:     New(options...),
+	}
+}
+
+// Verify validates an ID token. If nonce is not empty, the "nonce" claim must be equal to it.
+func (v *IDTokenVerifier) Verify(rawIDToken, nonce string) (*IDToken, error) {
+	header, payloadBytes, err := v.inst.verify(rawIDToken)
+	if err != nil {
+		return nil, validationError(err)
+	}
+	// The claims of a nested ID token are in the innermost token
+	if mediaTypeEqual(header.ContentType, "JWT") {
+		return v.Verify(string(payloadBytes), nonce)
+	}
+	if err := v.inst.checkType(&header); err != nil {
+		return nil, validationError(err)
+	}
+	if err := v.inst.checkClaims(payloadBytes); err != nil {
+		return nil, validationError(err)
+	}
+
+	idToken := &IDToken{algorithm: header.Algorithm}
+	if err := json.Unmarshal(payloadBytes, idToken); err != nil {
+		return nil, validationError(ErrInvalidTokenPayload)
+	}
+	if err := json.Unmarshal(payloadBytes, &idToken.Claims); err != nil {
+		return nil, validationError(ErrInvalidTokenPayload)
+	}
+
+	if nonce != "" && idToken.Nonce != nonce {
+		return nil, &ValidationError{Err: ErrInvalidNonce, Claim: "nonce"}
+	}
+
+	if len(idToken.Audience) > 1 && idToken.AuthorizedParty == "" {
+		return nil, &ValidationError{Err: ErrInvalidAuthorizedParty, Claim: "azp", Expected: v.clientID}
+	}
+	if idToken.AuthorizedParty != "" && idToken.AuthorizedParty != v.clientID {
+		return nil, &ValidationError{Err: ErrInvalidAuthorizedParty, Claim: "azp", Expected: v.clientID, Actual: []string{idToken.AuthorizedParty}}
+	}
+
+	if v.MaxAge != 0 {
+		if idToken.AuthTime == nil {
+			return nil, &ValidationError{Err: ErrMissingAuthTime, Claim: "auth_time"}
+		}
+		if v.inst.clock.Now().Add(-v.inst.leeway).After(idToken.AuthTime.Add(v.MaxAge)) {
+			return nil, &ValidationError{Err: ErrAuthTimeTooOld, Claim: "auth_time", Time: idToken.AuthTime.Time}
+		}
+	}
+
+	return idToken, nil
+}
+
+// VerifyOAuth2Token validates the ID token in the "id_token" field of an OAuth 2.0 token response.
+// If the ID token has an "at_hash" claim, it is checked against the access token.
+func (v *IDTokenVerifier) VerifyOAuth2Token(token *oauth2.Token, nonce string) (*IDToken, error) {
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, ErrMissingIDToken
+	}
+	idToken, err := v.Verify(rawIDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+	if idToken.AccessTokenHash != "" {
+		if err := idToken.VerifyAccessToken(token.AccessToken); err != nil {
+			return nil, err
+		}
+	}
+	return idToken, nil
+}
+
+// VerifyAccessToken checks that the "at_hash" claim of the ID token matches the given access token
+func (t *IDToken) VerifyAccessToken(accessToken string) error {
+	var hash crypto.Hash
+	switch {
+	case strings.HasSuffix(t.algorithm, "256"):
+		hash = crypto.SHA256
+	case strings.HasSuffix(t.algorithm, "384"):
+		hash = crypto.SHA384
+	case strings.HasSuffix(t.algorithm, "512"), t.algorithm == EdDSA:
+		hash = crypto.SHA512
+	default:
+		return ErrUnsupportedAlgorithm
+	}
+	sum := digest(hash, []byte(accessToken))
+	if encodeBytes(sum[:len(sum)/2]) != t.AccessTokenHash {
+		return &ValidationError{Err: ErrInvalidAccessTokenHash, Claim: "at_hash"}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package simplejwt_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/xyproto/simplejwt"
+	"golang.org/x/oauth2"
+)
+
+func TestOIDC(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	key := &simplejwt.JWK{Key: privateKey, KeyID: "idp-key", Algorithm: simplejwt.RS256}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                server.URL,
+			"authorization_endpoint":                server.URL + "/authorize",
+			"token_endpoint":                        server.URL + "/token",
+			"jwks_uri":                              server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(&simplejwt.JWKSet{Keys: []*simplejwt.JWK{key.Public()}})
+	})
+
+	provider, err := simplejwt.DiscoverOIDC(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Failed to discover provider: %v", err)
+	}
+	if provider.Endpoint().TokenURL != server.URL+"/token" {
+		t.Errorf("Expected token endpoint %s/token, got %s", server.URL, provider.Endpoint().TokenURL)
+	}
+
+	idp := simplejwt.New(simplejwt.WithJWK(key))
+	accessToken := "an-access-token"
+	atHash := sha256.Sum256([]byte(accessToken))
+	claims := simplejwt.Claims{
+		"iss":       server.URL,
+		"sub":       "alice",
+		"aud":       []string{"my-client", "other-client"},
+		"azp":       "my-client",
+		"exp":       simplejwt.NewNumericDate(time.Now().Add(time.Hour)),
+		"iat":       simplejwt.NewNumericDate(time.Now()),
+		"auth_time": simplejwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		"nonce":     "n-0S6_WzA2Mj",
+		"at_hash":   base64.RawURLEncoding.EncodeToString(atHash[:16]),
+		"email":     "alice@example.com",
+	}
+	issue := func(modify func(simplejwt.Claims)) string {
+		modified := simplejwt.Claims{}
+		for k, v := range claims {
+			modified[k] = v
+		}
+		modify(modified)
+		rawIDToken, err := idp.GenerateClaims(modified, &simplejwt.Header{KeyID: key.KeyID, Type: "JWT"})
+		if err != nil {
+			t.Fatalf("Failed to generate ID token: %v", err)
+		}
+		return rawIDToken
+	}
+
+	verifier := provider.Verifier("my-client")
+	verifier.MaxAge = 10 * time.Minute
+
+	token := (&oauth2.Token{AccessToken: accessToken}).WithExtra(map[string]any{"id_token": issue(func(simplejwt.Claims) {})})
+	idToken, err := verifier.VerifyOAuth2Token(token, "n-0S6_WzA2Mj")
+	if err != nil {
+		t.Fatalf("Failed to verify ID token: %v", err)
+	}
+	if idToken.Subject != "alice" || idToken.Claims["email"] != "alice@example.com" {
+		t.Errorf("Expected the claims of alice, got %+v", idToken)
+	}
+
+	// An access token from another response
+	wrongAccessToken := (&oauth2.Token{AccessToken: "another-access-token"}).WithExtra(map[string]any{"id_token": token.Extra("id_token")})
+	if _, err := verifier.VerifyOAuth2Token(wrongAccessToken, "n-0S6_WzA2Mj"); !errors.Is(err, simplejwt.ErrInvalidAccessTokenHash) {
+		t.Errorf("Expected ErrInvalidAccessTokenHash, got %v", err)
+	}
+
+	// A response without an ID token
+	if _, err := verifier.VerifyOAuth2Token(&oauth2.Token{AccessToken: accessToken}, "n-0S6_WzA2Mj"); !errors.Is(err, simplejwt.ErrMissingIDToken) {
+		t.Errorf("Expected ErrMissingIDToken, got %v", err)
+	}
+
+	for _, test := range []struct {
+		modify func(simplejwt.Claims)
+		err    error
+	}{
+		{func(c simplejwt.Claims) { c["nonce"] = "replayed" }, simplejwt.ErrInvalidNonce},
+		{func(c simplejwt.Claims) { c["aud"] = "other-client" }, simplejwt.ErrInvalidAudience},
+		{func(c simplejwt.Claims) { c["iss"] = "https://evil.example.com" }, simplejwt.ErrInvalidIssuer},
+		{func(c simplejwt.Claims) { c["azp"] = "other-client" }, simplejwt.ErrInvalidAuthorizedParty},
+		{func(c simplejwt.Claims) { delete(c, "azp") }, simplejwt.ErrInvalidAuthorizedParty},
+		{func(c simplejwt.Claims) { delete(c, "auth_time") }, simplejwt.ErrMissingAuthTime},
+		{func(c simplejwt.Claims) { c["auth_time"] = simplejwt.NewNumericDate(time.Now().Add(-time.Hour)) }, simplejwt.ErrAuthTimeTooOld},
+	} {
+		if _, err := verifier.Verify(issue(test.modify), "n-0S6_WzA2Mj"); !errors.Is(err, test.err) {
+			t.Errorf("Expected %v, got %v", test.err, err)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package simplejwt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"hash"
+	"os"
+)
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// pbkdf2PRFs maps the OIDs of the supported PBKDF2 pseudorandom functions to hash functions
+var pbkdf2PRFs = map[string]func() hash.Hash{
+	oidHMACWithSHA1.String():   sha1.New,
+	oidHMACWithSHA256.String(): sha256.New,
+	oidHMACWithSHA384.String(): sha512.New384,
+	oidHMACWithSHA512.String(): sha512.New,
+}
+
+// pbes2KeySizes maps the OIDs of the supported PBES2 encryption schemes to AES key sizes
+var pbes2KeySizes = map[string]int{
+	oidAES128CBC.String(): 16,
+	oidAES192CBC.String(): 24,
+	oidAES256CBC.String(): 32,
+}
+
+// ParsePrivateKeyDER parses a DER encoded PKCS#1, PKCS#8 or SEC1 private key.
+// The returned key is an *rsa.PrivateKey, *ecdsa.PrivateKey or ed25519.PrivateKey,
+// ready to be used with WithSigningKey.
+func ParsePrivateKeyDER(der []byte) (crypto.PrivateKey, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return supportedPrivateKey(key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, ErrInvalidKey
+}
+
+// ParsePrivateKeyPEM parses the first private key in PEM data, which can be a
+// "PRIVATE KEY" (PKCS#8), "RSA PRIVATE KEY" (PKCS#1) or "EC PRIVATE KEY" (SEC1) block
+func ParsePrivateKeyPEM(data []byte) (crypto.PrivateKey, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, ErrNoPEMData
+		}
+		switch block.Type {
+		case "PRIVATE KEY", "RSA PRIVATE KEY", "EC PRIVATE KEY":
+			if _, encrypted := block.Headers["DEK-Info"]; encrypted {
+				// Legacy OpenSSL encryption, which is insecure and not supported
+				return nil, ErrUnsupportedEncryption
+			}
+			return ParsePrivateKeyDER(block.Bytes)
+		case "ENCRYPTED PRIVATE KEY":
+			return nil, ErrEncryptedPrivateKey
+		}
+	}
+}
+
+// ParseEncryptedPrivateKeyPEM parses the first "ENCRYPTED PRIVATE KEY" block in PEM data,
+// which is an encrypted PKCS#8 private key. PBES2 with PBKDF2 and AES-CBC is supported,
+// which is what OpenSSL uses by default.
+func ParseEncryptedPrivateKeyPEM(data, password []byte) (crypto.PrivateKey, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, ErrNoPEMData
+		}
+		if block.Type == "ENCRYPTED PRIVATE KEY" {
+			return ParseEncryptedPrivateKeyDER(block.Bytes, password)
+		}
+	}
+}
+
+// ParsePublicKeyDER parses a DER encoded PKIX or PKCS#1 public key.
+// The returned key is an *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey,
+// ready to be used with WithVerificationKey.
+func ParsePublicKeyDER(der []byte) (crypto.PublicKey, error) {
+	if key, err := x509.ParsePKIXPublicKey(der); err == nil {
+		return supportedPublicKey(key)
+	}
+	if key, err := x509.ParsePKCS1PublicKey(der); err == nil {
+		return key, nil
+	}
+	return nil, ErrInvalidKey
+}
+
+// ParsePublicKeyPEM parses the first public key in PEM data, which can be a
+// "PUBLIC KEY" (PKIX), "RSA PUBLIC KEY" (PKCS#1) or "CERTIFICATE" block.
+// For a certificate, the public key of the certificate is returned.
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, ErrNoPEMData
+		}
+		switch block.Type {
+		case "PUBLIC KEY", "RSA PUBLIC KEY":
+			return ParsePublicKeyDER(block.Bytes)
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			return supportedPublicKey(cert.PublicKey)
+		}
+	}
+}
+
+// ParseCertificatesPEM parses all "CERTIFICATE" blocks in PEM data
+func ParseCertificatesPEM(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, ErrNoPEMData
+	}
+	return certs, nil
+}
+
+// isPEM checks if the data looks like PEM rather than DER
+func isPEM(data []byte) bool {
+	return bytes.Contains(data, []byte("-----BEGIN "))
+}
+
+// LoadPrivateKey reads a PEM or DER encoded private key from a file
+func LoadPrivateKey(filename string) (crypto.PrivateKey, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if isPEM(data) {
+		return ParsePrivateKeyPEM(data)
+	}
+	return ParsePrivateKeyDER(data)
+}
+
+// LoadEncryptedPrivateKey reads a PEM or DER encoded, encrypted PKCS#8 private key from a file
+func LoadEncryptedPrivateKey(filename string, password []byte) (crypto.PrivateKey, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if isPEM(data) {
+		return ParseEncryptedPrivateKeyPEM(data, password)
+	}
+	return ParseEncryptedPrivateKeyDER(data, password)
+}
+
+// LoadPublicKey reads a PEM or DER encoded public key, or the public key of a certificate, from a file
+func LoadPublicKey(filename string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if isPEM(data) {
+		return ParsePublicKeyPEM(data)
+	}
+	if key, err := ParsePublicKeyDER(data); err == nil {
+		return key, nil
+	}
+	cert, err := x509.ParseCertificate(data)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	return supportedPublicKey(cert.PublicKey)
+}
+
+// LoadCertificates reads PEM or DER encoded X.509 certificates from a file
+func LoadCertificates(filename string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if isPEM(data) {
+		return ParseCertificatesPEM(data)
+	}
+	return x509.ParseCertificates(data)
+}
+
+// supportedPrivateKey returns the key if it is of a type that can be used for signing tokens
+func supportedPrivateKey(key any) (crypto.PrivateKey, error) {
+	switch key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		return key, nil
+	}
+	return nil, ErrUnsupportedKeyType
+}
+
+// supportedPublicKey returns the key if it is of a type that can be used for validating tokens
+func supportedPublicKey(key any) (crypto.PublicKey, error) {
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	}
+	return nil, ErrUnsupportedKeyType
+}
+
+// pkixAlgorithm is an ASN.1 AlgorithmIdentifier
+type pkixAlgorithm struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+// encryptedPrivateKeyInfo is an encrypted PKCS#8 private key, as described in RFC 5208
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkixAlgorithm
+	EncryptedData []byte
+}
+
+// pbes2Params are the parameters of PBES2, as described in RFC 8018
+type pbes2Params struct {
+	KeyDerivationFunc pkixAlgorithm
+	EncryptionScheme  pkixAlgorithm
+}
+
+// pbkdf2Params are the parameters of PBKDF2, as described in RFC 8018
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int           `asn1:"optional"`
+	PRF            pkixAlgorithm `asn1:"optional"`
+}
+
+// ParseEncryptedPrivateKeyDER decrypts and parses a DER encoded, encrypted PKCS#8 private key
+func ParseEncryptedPrivateKeyDER(der, password []byte) (crypto.PrivateKey, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, ErrInvalidKey
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, ErrUnsupportedEncryption
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, ErrInvalidKey
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, ErrUnsupportedEncryption
+	}
+	var kdfParams pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+		return nil, ErrInvalidKey
+	}
+	prf := sha1.New
+	if len(kdfParams.PRF.Algorithm) > 0 {
+		var ok bool
+		if prf, ok = pbkdf2PRFs[kdfParams.PRF.Algorithm.String()]; !ok {
+			return nil, ErrUnsupportedEncryption
+		}
+	}
+	keySize, ok := pbes2KeySizes[params.EncryptionScheme.Algorithm.String()]
+	if !ok {
+		return nil, ErrUnsupportedEncryption
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil || len(iv) != aes.BlockSize {
+		return nil, ErrInvalidKey
+	}
+	if len(info.EncryptedData) == 0 || len(info.EncryptedData)%aes.BlockSize != 0 || kdfParams.IterationCount < 1 {
+		return nil, ErrInvalidKey
+	}
+
+	key := pbkdf2(password, kdfParams.Salt, kdfParams.IterationCount, keySize, prf)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, info.EncryptedData)
+	plaintext, err = unpad(plaintext, aes.BlockSize)
+	if err != nil {
+		return nil, ErrIncorrectPassword
+	}
+	privateKey, err := x509.ParsePKCS8PrivateKey(plaintext)
+	if err != nil {
+		return nil, ErrIncorrectPassword
+	}
+	return supportedPrivateKey(privateKey)
+}
+
+// pbkdf2 derives a key from a password, as described in RFC 8018 section 5.2
+func pbkdf2(password, salt []byte, iterations, keyLength int, h func() hash.Hash) []byte {
+	prf := hmac.New(h, password)
+	var key []byte
+	for block := uint32(1); len(key) < keyLength; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write([]byte{byte(block >> 24), byte(block >> 16), byte(block >> 8), byte(block)})
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLength]
+}
+
+// unpad removes PKCS#7 padding
+func unpad(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 || len(data)%blockSize != 0 {
+		return nil, ErrInvalidKey
+	}
+	n := int(data[len(data)-1])
+	if n == 0 || n > blockSize || n > len(data) {
+		return nil, ErrInvalidKey
+	}
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, ErrInvalidKey
+		}
+	}
+	return data[:len(data)-n], nil
+}
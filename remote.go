@@ -0,0 +1,245 @@
+package simplejwt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RemoteKeySet is a KeySource that fetches a JWK Set from a URL, such as the JWKS URL
+// of an identity provider. The keys are cached for as long as the Cache-Control header
+// of the response allows, and are refetched when a token has an unknown "kid", but
+// never more often than the refresh interval.
+type RemoteKeySet struct {
+	url             string
+	client          *http.Client
+	clock           Clock
+	refreshInterval time.Duration
+	defaultMaxAge   time.Duration
+	fetchTimeout    time.Duration
+
+	fetching  chan struct{} // holds a value while fetching, so that only one fetch happens at a time
+	mut       sync.RWMutex
+	set       *JWKSet
+	expires   time.Time
+	lastFetch time.Time
+}
+
+// RemoteOption is a functional option that can be passed to NewRemoteKeySet
+type RemoteOption func(*RemoteKeySet)
+
+// WithHTTPClient sets the HTTP client used for fetching the JWK Set
+func WithHTTPClient(client *http.Client) RemoteOption {
+	return func(r *RemoteKeySet) {
+		r.client = client
+	}
+}
+
+// WithRefreshInterval sets the minimum time between two fetches of the JWK Set.
+// The default is one minute.
+func WithRefreshInterval(interval time.Duration) RemoteOption {
+	return func(r *RemoteKeySet) {
+		r.refreshInterval = interval
+	}
+}
+
+// WithDefaultMaxAge sets how long the JWK Set is cached when the response has no
+// Cache-Control max-age directive. The default is one hour.
+func WithDefaultMaxAge(maxAge time.Duration) RemoteOption {
+	return func(r *RemoteKeySet) {
+		r.defaultMaxAge = maxAge
+	}
+}
+
+// WithFetchTimeout sets how long a fetch of the JWK Set may take, also when the HTTP client
+// has no timeout, and how long LookupKey waits for a fetch that is already in progress.
+// The default is ten seconds.
+func WithFetchTimeout(timeout time.Duration) RemoteOption {
+	return func(r *RemoteKeySet) {
+		r.fetchTimeout = timeout
+	}
+}
+
+// WithRemoteClock sets the clock used for deciding when the cached JWK Set has expired.
+// The clock is not used for scheduling the background refreshes of Start, which use real timers.
+func WithRemoteClock(clock Clock) RemoteOption {
+	return func(r *RemoteKeySet) {
+		r.clock = clock
+	}
+}
+
+// NewRemoteKeySet creates a RemoteKeySet for the JWK Set at the given URL.
+// Nothing is fetched until a key is looked up, or Refresh or Start is called.
+func NewRemoteKeySet(url string, options ...RemoteOption) *RemoteKeySet {
+	r := &RemoteKeySet{
+		url:             url,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		clock:           systemClock{},
+		refreshInterval: time.Minute,
+		defaultMaxAge:   time.Hour,
+		fetchTimeout:    10 * time.Second,
+		fetching:        make(chan struct{}, 1),
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// Refresh fetches the JWK Set right away. If fetching fails, the previously fetched keys are kept.
+func (r *RemoteKeySet) Refresh(ctx context.Context) error {
+	if err := r.lockFetch(ctx); err != nil {
+		return err
+	}
+	defer r.unlockFetch()
+	return r.fetch(ctx)
+}
+
+// lockFetch waits until no other fetch is in progress, or until the context is done
+func (r *RemoteKeySet) lockFetch(ctx context.Context) error {
+	select {
+	case r.fetching <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// unlockFetch lets the next fetch start
+func (r *RemoteKeySet) unlockFetch() {
+	<-r.fetching
+}
+
+// refreshIfAllowed fetches the JWK Set, unless it was fetched less than the refresh interval ago
+func (r *RemoteKeySet) refreshIfAllowed(ctx context.Context) error {
+	if err := r.lockFetch(ctx); err != nil {
+		return err
+	}
+	defer r.unlockFetch()
+	r.mut.RLock()
+	recent := !r.lastFetch.IsZero() && r.clock.Now().Sub(r.lastFetch) < r.refreshInterval
+	r.mut.RUnlock()
+	if recent {
+		return nil
+	}
+	return r.fetch(ctx)
+}
+
+// fetch fetches and parses the JWK Set, giving up after the fetch timeout. lockFetch must have been called.
+func (r *RemoteKeySet) fetch(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.fetchTimeout)
+	defer cancel()
+
+	now := r.clock.Now()
+	r.mut.Lock()
+	r.lastFetch = now
+	r.mut.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching %s: %s", r.url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	set, err := ParseJWKSet(data)
+	if err != nil {
+		return err
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.set = set
+	r.expires = now.Add(r.maxAge(resp.Header.Get("Cache-Control")))
+	return nil
+}
+
+// maxAge returns how long a response with the given Cache-Control header can be cached
+func (r *RemoteKeySet) maxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store" || directive == "no-cache":
+			return 0
+		case strings.HasPrefix(directive, "max-age="):
+			seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+			if err == nil && seconds >= 0 {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return r.defaultMaxAge
+}
+
+// cached returns the cached JWK Set, and if it has expired
+func (r *RemoteKeySet) cached() (*JWKSet, bool) {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	return r.set, !r.clock.Now().Before(r.expires)
+}
+
+// LookupKey returns the key that matches the "kid" header of a token, fetching the JWK Set
+// if it has not been fetched yet, if the cached keys have expired, or if the key is unknown.
+// Both waiting for a fetch that is already in progress and fetching are given up after the fetch timeout.
+func (r *RemoteKeySet) LookupKey(header *Header) (*JWK, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.fetchTimeout)
+	defer cancel()
+	set, expired := r.cached()
+	if set == nil || expired {
+		if err := r.refreshIfAllowed(ctx); err != nil && set == nil {
+			return nil, err
+		}
+		set, _ = r.cached()
+		if set == nil {
+			return nil, ErrKeyNotFound
+		}
+	}
+	if key, err := set.LookupKey(header); err == nil {
+		return key, nil
+	}
+	// The identity provider may have rotated its keys
+	if err := r.refreshIfAllowed(ctx); err != nil {
+		return nil, err
+	}
+	set, _ = r.cached()
+	return set.LookupKey(header)
+}
+
+// Start refreshes the JWK Set in the background whenever the cached keys expire,
+// until the context is canceled. The refreshes are scheduled with real timers, so a clock
+// given with WithRemoteClock only decides when the keys expire, not when Start refreshes them.
+func (r *RemoteKeySet) Start(ctx context.Context) {
+	go func() {
+		for {
+			r.refreshIfAllowed(ctx)
+			r.mut.RLock()
+			wait := r.expires.Sub(r.clock.Now())
+			r.mut.RUnlock()
+			if wait < r.refreshInterval {
+				wait = r.refreshInterval
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
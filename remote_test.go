@@ -0,0 +1,181 @@
+package simplejwt_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xyproto/simplejwt"
+)
+
+// jwksServer serves the public keys of a JWK Set, and counts the requests
+type jwksServer struct {
+	mut          sync.Mutex
+	set          *simplejwt.JWKSet
+	cacheControl string
+	requests     int32
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	atomic.AddInt32(&s.requests, 1)
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	w.Header().Set("Cache-Control", s.cacheControl)
+	json.NewEncoder(w).Encode(s.set.Public())
+}
+
+func (s *jwksServer) add(t *testing.T, keyID string) *simplejwt.JWK {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	key := &simplejwt.JWK{Key: privateKey, KeyID: keyID, Algorithm: simplejwt.ES256}
+	s.mut.Lock()
+	s.set.Keys = append(s.set.Keys, key)
+	s.mut.Unlock()
+	return key
+}
+
+func TestRemoteKeySet(t *testing.T) {
+	handler := &jwksServer{set: &simplejwt.JWKSet{}, cacheControl: "public, max-age=600"}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	now := time.Now()
+	clock := simplejwt.ClockFunc(func() time.Time { return now })
+
+	keys := simplejwt.NewRemoteKeySet(server.URL, simplejwt.WithRemoteClock(clock), simplejwt.WithRefreshInterval(time.Minute))
+	verifier := simplejwt.New(simplejwt.WithKeySource(keys), simplejwt.WithAlgorithm(simplejwt.ES256))
+
+	payload := simplejwt.Payload{Subject: "alice", Expires: now.Add(time.Hour)}
+	issue := func(key *simplejwt.JWK) string {
+		token, err := simplejwt.New(simplejwt.WithJWK(key)).Generate(payload, &simplejwt.Header{KeyID: key.KeyID, Type: "JWT"})
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+		return token
+	}
+
+	first := handler.add(t, "first")
+	for i := 0; i < 3; i++ {
+		if _, err := verifier.Validate(issue(first)); err != nil {
+			t.Fatalf("Failed to validate token: %v", err)
+		}
+	}
+	if requests := atomic.LoadInt32(&handler.requests); requests != 1 {
+		t.Errorf("Expected the JWK Set to be fetched once, got %d requests", requests)
+	}
+
+	// An unknown kid is only refetched once per refresh interval
+	second := handler.add(t, "second")
+	now = now.Add(10 * time.Second)
+	if _, err := verifier.Validate(issue(second)); !errors.Is(err, simplejwt.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound within the refresh interval, got %v", err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := verifier.Validate(issue(second)); err != nil {
+		t.Errorf("Failed to validate token with a new key: %v", err)
+	}
+	if requests := atomic.LoadInt32(&handler.requests); requests != 2 {
+		t.Errorf("Expected 2 requests, got %d", requests)
+	}
+
+	// The keys are refetched when the max-age from Cache-Control has passed
+	now = now.Add(11 * time.Minute)
+	if _, err := verifier.Validate(issue(first)); err != nil {
+		t.Errorf("Failed to validate token: %v", err)
+	}
+	if requests := atomic.LoadInt32(&handler.requests); requests != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests)
+	}
+}
+
+func TestRemoteKeySetBackgroundRefresh(t *testing.T) {
+	handler := &jwksServer{set: &simplejwt.JWKSet{}, cacheControl: "no-cache"}
+	handler.add(t, "first")
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	keys := simplejwt.NewRemoteKeySet(server.URL, simplejwt.WithRefreshInterval(10*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	keys.Start(ctx)
+	defer cancel()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&handler.requests) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the JWK Set to be refreshed in the background")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err := keys.LookupKey(&simplejwt.Header{KeyID: "first"}); err != nil {
+		t.Errorf("Failed to look up key: %v", err)
+	}
+}
+
+func TestRemoteKeySetFetchTimeout(t *testing.T) {
+	// A JWKS endpoint that never answers
+	requested := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	keys := simplejwt.NewRemoteKeySet(server.URL, simplejwt.WithHTTPClient(&http.Client{}), simplejwt.WithFetchTimeout(100*time.Millisecond))
+
+	// Start owns the fetch that hangs
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	keys.Start(ctx)
+	select {
+	case <-requested:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected Start to fetch the JWK Set")
+	}
+
+	// Lookups are not blocked for longer than the timeout
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := keys.LookupKey(&simplejwt.Header{KeyID: "first"}); err == nil {
+				t.Errorf("Expected an error when the JWK Set can not be fetched")
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the lookups to give up after the fetch timeout, took %v", elapsed)
+	}
+
+	// A fetch that hangs is given up after the timeout, also for Refresh
+	start = time.Now()
+	if err := keys.Refresh(context.Background()); err == nil {
+		t.Errorf("Expected an error when the JWK Set can not be fetched")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected Refresh to give up after the fetch timeout, took %v", elapsed)
+	}
+}
@@ -1,116 +1,677 @@
 // Package simplejwt provides a simple JWT implementation for generating
-// and validating JWT tokens with HMAC SHA256 signatures.
+// and validating JWT tokens with HMAC, RSA, ECDSA or Ed25519 signatures.
 package simplejwt
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
+	"crypto"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"strings"
 	"time"
 )
 
-// Payload represents the payload of a JWT token
+// Payload represents the payload of a JWT token, with the registered claims from RFC 7519
 type Payload struct {
-	Subject string    `json:"sub"`
-	Expires time.Time `json:"exp"`
+	Subject   string    `json:"sub"`
+	Expires   time.Time `json:"exp"`
+	Issuer    string    `json:"iss,omitempty"`
+	Audience  Audience  `json:"aud,omitempty"`
+	NotBefore time.Time `json:"nbf"`
+	IssuedAt  time.Time `json:"iat"`
+	ID        string    `json:"jti,omitempty"`
 }
 
 // Header represents the header of a JWT token
 type Header struct {
-	Algorithm string `json:"alg"`
-	Type      string `json:"typ"`
+	Algorithm          string   `json:"alg"`
+	Type               string   `json:"typ,omitempty"`
+	ContentType        string   `json:"cty,omitempty"`
+	KeyID              string   `json:"kid,omitempty"`
+	X509CertChain      []string `json:"x5c,omitempty"`
+	X509Thumbprint     string   `json:"x5t,omitempty"`
+	X509ThumbprintS256 string   `json:"x5t#S256,omitempty"`
+	X509URL            string   `json:"x5u,omitempty"`
+
+	// Base64 is false for tokens with an unencoded payload, as described in RFC 7797.
+	// Critical lists the header parameters that must be understood when validating.
+	Base64   *bool    `json:"b64,omitempty"`
+	Critical []string `json:"crit,omitempty"`
+
+	// Encryption and EphemeralPublicKey are used by encrypted tokens
+	Encryption         string `json:"enc,omitempty"`
+	EphemeralPublicKey *JWK   `json:"epk,omitempty"`
+
+	// params holds all the parameters of a decoded header, including unknown ones
+	params map[string]json.RawMessage
+}
+
+// Instance holds the keys, algorithm, clock and validation rules that are
+// used when generating and validating JWT tokens. Different instances can use
+// different keys within the same process.
+type Instance struct {
+	signingKey      any
+	verificationKey any
+	keyring         *Keyring
+	keySource       KeySource
+	certChain       []*x509.Certificate
+	certRoots       *x509.CertPool
+	algorithm       string
+	tokenType       string
+	requiredType    string
+	allowedAlgs     []string
+	allowNone       bool
+	strictKeyLength bool
+	anyPSSSalt      bool
+	issuer          string
+	audience        string
+	legacyPadding   bool
+	unencoded       bool
+
+	criticalHandlers map[string]CriticalHandler
+	leeway           time.Duration
+	clock            Clock
+
+	encryptionAlgorithm string
+	contentEncryption   string
+	encryptionKey       any
+
+	decryptionAlgorithm  string
+	decryptionEncryption string
+	decryptionKey        any
 }
 
+// Option is a functional option that can be passed to New
+type Option func(*Instance)
+
 var (
-	secretKey                = []byte("your-secret-key")
-	errInvalidTokenFormat    = errors.New("invalid token format")
-	errInvalidTokenSignature = errors.New("invalid token signature")
-	errInvalidTokenPayload   = errors.New("invalid token payload")
-	errTokenExpired          = errors.New("token has expired")
+	defaultSecret   = "your-secret-key"
+	defaultInstance = New(WithSecret(defaultSecret))
 )
 
-// SetSecret sets the secret key used for generating and validating JWT tokens.
+// New creates a new Instance. The instance has no key until one is given with an option
+// such as WithSecret or WithSigningKey, and generating or validating tokens fails with
+// ErrKeyTypeMismatch until then.
+func New(options ...Option) *Instance {
+	inst := &Instance{
+		tokenType: "JWT",
+		clock:     systemClock{},
+	}
+	for _, option := range options {
+		option(inst)
+	}
+	if inst.algorithm == "" {
+		inst.algorithm = defaultAlgorithm(inst.verificationKey)
+	}
+	return inst
+}
+
+// WithSecret sets the secret key used for generating and validating JWT tokens
+func WithSecret(secret string) Option {
+	return func(inst *Instance) {
+		inst.signingKey = []byte(secret)
+		inst.verificationKey = []byte(secret)
+	}
+}
+
+// WithAlgorithm sets the algorithm that is written to the "alg" header of generated tokens,
+// unless a custom header with another algorithm is given to Generate.
+func WithAlgorithm(alg string) Option {
+	return func(inst *Instance) {
+		inst.algorithm = alg
+	}
+}
+
+// WithType sets the "typ" header of generated tokens, such as "at+jwt" for access tokens
+// as described in RFC 9068. The default is "JWT".
+func WithType(typ string) Option {
+	return func(inst *Instance) {
+		inst.tokenType = typ
+	}
+}
+
+// WithRequiredType makes Validate reject tokens where the "typ" header is not the given type,
+// so that a token of one kind can not be used where another kind is expected, as recommended
+// by RFC 8725. The comparison is case-insensitive, and the "application/" prefix is ignored.
+func WithRequiredType(typ string) Option {
+	return func(inst *Instance) {
+		inst.requiredType = typ
+	}
+}
+
+// WithAllowedAlgorithms sets the algorithms that are accepted in the "alg" header of tokens
+// that are validated. Without it, only the algorithm of the instance is accepted, and tokens
+// validated with a KeySource may also use the algorithm of the key that matches their "kid".
+// Either way, the token can not choose an algorithm for a key of another type.
+func WithAllowedAlgorithms(algs ...string) Option {
+	return func(inst *Instance) {
+		inst.allowedAlgs = algs
+	}
+}
+
+// WithInsecureNoneAlgorithm makes the instance accept and generate unsecured tokens with "alg"
+// set to "none" and an empty signature. This must only be used in tests.
+func WithInsecureNoneAlgorithm() Option {
+	return func(inst *Instance) {
+		inst.allowNone = true
+	}
+}
+
+// WithStrictKeyLength makes the instance refuse HMAC secrets that are shorter than
+// the hash output of the algorithm, such as secrets shorter than 32 bytes for HS256.
+func WithStrictKeyLength() Option {
+	return func(inst *Instance) {
+		inst.strictKeyLength = true
+	}
+}
+
+// WithAnyPSSSaltLength makes the instance accept PS256, PS384 and PS512 signatures with any
+// salt length, for tokens from libraries that do not follow RFC 7518, which requires the salt
+// to be as long as the hash output. Generated signatures always follow RFC 7518.
+func WithAnyPSSSaltLength() Option {
+	return func(inst *Instance) {
+		inst.anyPSSSalt = true
+	}
+}
+
+// WithLegacyPadding makes Validate accept tokens where the parts are base64url encoded with
+// "=" padding, as generated by earlier versions of this package. Generated tokens are never padded.
+func WithLegacyPadding() Option {
+	return func(inst *Instance) {
+		inst.legacyPadding = true
+	}
+}
+
+// WithUnencodedPayload makes the instance generate tokens with the "b64" header set to false,
+// as described in RFC 7797, so that the payload is signed as it is instead of base64url encoded.
+// This is mainly useful together with SignDetached. Tokens with an unencoded payload are always
+// accepted when validating, as long as "b64" is listed in the "crit" header.
+func WithUnencodedPayload() Option {
+	return func(inst *Instance) {
+		inst.unencoded = true
+	}
+}
+
+// WithLeeway sets how much clock skew to allow for when checking the "exp", "nbf" and "iat" claims
+func WithLeeway(leeway time.Duration) Option {
+	return func(inst *Instance) {
+		inst.leeway = leeway
+	}
+}
+
+// WithClock sets the clock that is used for generating and validating tokens
+func WithClock(clock Clock) Option {
+	return func(inst *Instance) {
+		inst.clock = clock
+	}
+}
+
+// WithRequiredIssuer makes Validate reject tokens where the "iss" claim is not the given issuer
+func WithRequiredIssuer(issuer string) Option {
+	return func(inst *Instance) {
+		inst.issuer = issuer
+	}
+}
+
+// WithRequiredAudience makes Validate reject tokens where the "aud" claim does not contain the given audience
+func WithRequiredAudience(audience string) Option {
+	return func(inst *Instance) {
+		inst.audience = audience
+	}
+}
+
+// WithSigningKey sets the algorithm and the private key used for generating JWT tokens.
+// The corresponding public key is used for validating JWT tokens.
+// For RS256, RS384, RS512, PS256, PS384 and PS512, the key must be an *rsa.PrivateKey.
+// For ES256, ES384 and ES512, the key must be an *ecdsa.PrivateKey on the P-256, P-384 or P-521 curve.
+// For EdDSA, the key must be an ed25519.PrivateKey.
+func WithSigningKey(alg string, key crypto.PrivateKey) Option {
+	return func(inst *Instance) {
+		inst.algorithm = alg
+		inst.signingKey = key
+		inst.verificationKey = publicKey(key)
+	}
+}
+
+// WithJWK sets the key used for generating and validating JWT tokens from a JSON Web Key.
+// If the JWK only contains a public key, it is only used for validating tokens.
+// If the JWK has no "alg", the algorithm is chosen from the key type.
+func WithJWK(jwk *JWK) Option {
+	return func(inst *Instance) {
+		alg := jwk.algorithm()
+		if jwk.IsPrivate() {
+			WithSigningKey(alg, jwk.Key)(inst)
+			return
+		}
+		inst.algorithm = alg
+		inst.verificationKey = jwk.Key
+	}
+}
+
+// WithKeyring makes the instance generate tokens with the active key of the keyring,
+// and validate tokens with the key in the keyring that matches their "kid" header.
+func WithKeyring(keyring *Keyring) Option {
+	return func(inst *Instance) {
+		inst.keyring = keyring
+		inst.keySource = keyring
+	}
+}
+
+// WithKeySource makes the instance validate tokens with the keys from the given key source,
+// such as a JWKSet or a Keyring, instead of a single verification key
+func WithKeySource(keySource KeySource) Option {
+	return func(inst *Instance) {
+		inst.keySource = keySource
+	}
+}
+
+// WithCertificateChain makes the instance add the given certificate chain to the "x5c"
+// header of generated tokens, together with the "x5t#S256" thumbprint of the first certificate.
+// The first certificate must be the certificate of the signing key.
+func WithCertificateChain(certs []*x509.Certificate) Option {
+	return func(inst *Instance) {
+		inst.certChain = certs
+	}
+}
+
+// WithCertificateRoots makes the instance validate tokens with the key of the first
+// certificate in the "x5c" header, after verifying the certificate chain against the given roots.
+// Tokens without an "x5c" header are rejected.
+func WithCertificateRoots(roots *x509.CertPool) Option {
+	return func(inst *Instance) {
+		inst.certRoots = roots
+	}
+}
+
+// WithVerificationKey sets the public key used for validating JWT tokens,
+// for instances that only need to validate tokens signed by others.
+// For RS256, RS384, RS512, PS256, PS384 and PS512, the key must be an *rsa.PublicKey.
+// For ES256, ES384 and ES512, the key must be an *ecdsa.PublicKey.
+// For EdDSA, the key must be an ed25519.PublicKey.
+// HMAC secrets are not accepted here, so that a public key in PEM format can not be
+// mistaken for one. Use WithSecret for HMAC.
+func WithVerificationKey(key crypto.PublicKey) Option {
+	return func(inst *Instance) {
+		inst.verificationKey = nil
+		if keyType(key) != "oct" {
+			inst.verificationKey = publicKey(key)
+		}
+	}
+}
+
+// WithEncryptionKey sets the key management algorithm, the content encryption algorithm
+// and the key used by GenerateEncrypted. See Encrypt for which keys can be used.
+func WithEncryptionKey(alg, enc string, key any) Option {
+	return func(inst *Instance) {
+		inst.encryptionAlgorithm = alg
+		inst.contentEncryption = enc
+		inst.encryptionKey = key
+	}
+}
+
+// WithDecryptionKey sets the key management algorithm, the content encryption algorithm
+// and the key used by ValidateEncrypted and ValidateEncryptedInto. Encrypted tokens with
+// other "alg" or "enc" headers are rejected. See Decrypt for which keys can be used.
+func WithDecryptionKey(alg, enc string, key any) Option {
+	return func(inst *Instance) {
+		inst.decryptionAlgorithm = alg
+		inst.decryptionEncryption = enc
+		inst.decryptionKey = key
+	}
+}
+
+// SetSecret sets the secret key used by the package-level functions
+// for generating and validating JWT tokens. The secret replaces any other key of the
+// default instance, while the other options given to SetDefault, such as WithLeeway, are kept.
 func SetSecret(secret string) {
-	secretKey = []byte(secret)
+	inst := *defaultInstance
+	inst.keyring = nil
+	inst.keySource = nil
+	inst.certChain = nil
+	WithSecret(secret)(&inst)
+	if alg, err := lookupAlgorithm(inst.algorithm); err != nil || alg.keyType() != "oct" {
+		inst.algorithm = HS256
+	}
+	defaultInstance = &inst
+}
+
+// SetDefault replaces the instance used by the package-level functions
+// with a new instance that is created with the given options. Earlier calls to
+// SetSecret and SetDefault are forgotten, so a secret must be given with WithSecret.
+func SetDefault(options ...Option) {
+	defaultInstance = New(options...)
 }
 
 // Generate generates a JWT token with the provided payload and an optional custom header.
-func Generate(payload Payload, customHeader *Header) (string, error) {
-	header := Header{
-		Algorithm: "HS256",
-		Type:      "JWT",
+// The "alg" field of the header selects the signature algorithm.
+func (inst *Instance) Generate(payload Payload, customHeader *Header) (string, error) {
+	return inst.GenerateClaims(payload, customHeader)
+}
+
+// GenerateClaims generates a JWT token with arbitrary claims and an optional custom header.
+// The claims can be a struct, a Claims map or anything else that marshals to a JSON object.
+// If the instance has a keyring, the "kid" header is set to the ID of the active key.
+func (inst *Instance) GenerateClaims(claims any, customHeader *Header) (string, error) {
+	payloadBytes, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
 	}
 
+	return inst.generate(payloadBytes, customHeader)
+}
+
+// GenerateNested signs an already signed JWT token again, with "cty" set to "JWT",
+// as described in RFC 7519 section 5.2. The header is optional, and "typ" is "JWT" by default.
+// The validation methods validate nested tokens all the way to the innermost token,
+// which holds the claims.
+func (inst *Instance) GenerateNested(innerToken string, customHeader *Header) (string, error) {
+	header := Header{Type: "JWT"}
 	if customHeader != nil {
 		header = *customHeader
 	}
+	header.ContentType = "JWT"
+	return inst.generate([]byte(innerToken), &header)
+}
 
-	headerBytes, err := json.Marshal(header)
+// generate signs the given payload and returns a token in compact serialization
+func (inst *Instance) generate(payloadBytes []byte, customHeader *Header) (string, error) {
+	payloadEncoded := inst.payloadSegment(payloadBytes)
+	if strings.Contains(payloadEncoded, ".") {
+		return "", ErrInvalidTokenPayload
+	}
+
+	headerEncoded, signature, err := inst.sign(payloadEncoded, customHeader)
 	if err != nil {
 		return "", err
 	}
 
-	headerEncoded := base64.URLEncoding.EncodeToString(headerBytes)
-	payloadBytes, err := json.Marshal(payload)
+	return fmt.Sprintf("%s.%s.%s", headerEncoded, payloadEncoded, signature), nil
+}
+
+// sign creates the header for a token and signs it together with the already encoded payload.
+// The encoded header and the encoded signature are returned.
+func (inst *Instance) sign(payloadEncoded string, customHeader *Header) (headerEncoded, signature string, err error) {
+	signingKey, algorithm, keyID := inst.signingKey, inst.algorithm, ""
+	if inst.keyring != nil {
+		key, err := inst.keyring.ActiveKey()
+		if err != nil {
+			return "", "", err
+		}
+		signingKey, algorithm, keyID = key.Key, key.algorithm(), key.KeyID
+	}
+
+	header := Header{
+		Algorithm: algorithm,
+		Type:      inst.tokenType,
+		KeyID:     keyID,
+	}
+
+	if customHeader != nil {
+		header = *customHeader
+		if header.Algorithm == "" {
+			header.Algorithm = algorithm
+		}
+		if header.KeyID == "" {
+			header.KeyID = keyID
+		}
+	}
+
+	if inst.unencoded {
+		b64 := false
+		header.Base64 = &b64
+		if !header.isCritical("b64") {
+			// Copy the list first, so that the header of the caller is not changed
+			header.Critical = append(append([]string(nil), header.Critical...), "b64")
+		}
+	}
+
+	if len(inst.certChain) > 0 && len(header.X509CertChain) == 0 {
+		setCertificateChain(&header, inst.certChain)
+	}
+
+	alg, err := inst.lookupAlgorithm(header.Algorithm)
 	if err != nil {
-		return "", err
+		return "", "", err
+	}
+
+	if inst.strictKeyLength {
+		if err := checkKeyLength(alg, signingKey); err != nil {
+			return "", "", err
+		}
 	}
 
-	payloadEncoded := base64.URLEncoding.EncodeToString(payloadBytes)
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		return "", "", err
+	}
 
-	token := fmt.Sprintf("%s.%s", headerEncoded, payloadEncoded)
-	mac := hmac.New(sha256.New, secretKey)
-	mac.Write([]byte(token))
-	signature := base64.URLEncoding.EncodeToString(mac.Sum(nil))
+	headerEncoded = base64.RawURLEncoding.EncodeToString(headerBytes)
 
-	token = fmt.Sprintf("%s.%s", token, signature)
+	signatureBytes, err := alg.sign(signingKey, []byte(headerEncoded+"."+payloadEncoded))
+	if err != nil {
+		return "", "", err
+	}
 
-	return token, nil
+	return headerEncoded, base64.RawURLEncoding.EncodeToString(signatureBytes), nil
 }
 
 // Validate validates a JWT token and returns the decoded payload if the token is valid.
-func Validate(token string) (Payload, error) {
+// Tokens with an "alg" header that does not match the type of the verification key are rejected.
+func (inst *Instance) Validate(token string) (Payload, error) {
+	var payload Payload
+	if err := inst.ValidateInto(token, &payload); err != nil {
+		return Payload{}, err
+	}
+	return payload, nil
+}
+
+// ValidateInto validates a JWT token and decodes the claims into the value pointed to by claims,
+// which can be a pointer to a struct with custom claims. The registered claims, like "exp", are
+// enforced regardless of which fields the struct has.
+// If the token is not valid, the returned error is a *ValidationError.
+func (inst *Instance) ValidateInto(token string, claims any) error {
+	header, payloadBytes, err := inst.verify(token)
+	if err != nil {
+		return validationError(err)
+	}
+
+	return inst.validatePayload(&header, payloadBytes, claims)
+}
+
+// validatePayload checks the type and the claims of a token with a verified signature, and decodes
+// the claims. If the token is a nested JWT, the inner token is validated instead, since the claims
+// are in the innermost token.
+func (inst *Instance) validatePayload(header *Header, payloadBytes []byte, claims any) error {
+	if mediaTypeEqual(header.ContentType, "JWT") {
+		return inst.ValidateInto(string(payloadBytes), claims)
+	}
+
+	if err := inst.checkType(header); err != nil {
+		return validationError(err)
+	}
+
+	if err := inst.checkClaims(payloadBytes); err != nil {
+		return validationError(err)
+	}
+
+	if err := json.Unmarshal(payloadBytes, claims); err != nil {
+		return validationError(ErrInvalidTokenPayload)
+	}
+
+	return nil
+}
+
+// ValidateClaims validates a JWT token and returns all of its claims as a map
+func (inst *Instance) ValidateClaims(token string) (Claims, error) {
+	var claims Claims
+	if err := inst.ValidateInto(token, &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// decodeSegment decodes one of the unpadded base64url encoded parts of a token.
+// Padded parts are only accepted if WithLegacyPadding is used.
+func (inst *Instance) decodeSegment(segment string) ([]byte, error) {
+	if inst.legacyPadding {
+		segment = strings.TrimRight(segment, "=")
+	}
+	return base64.RawURLEncoding.DecodeString(segment)
+}
+
+// verify checks the signature of a JWT token and returns its decoded header and payload
+func (inst *Instance) verify(token string) (Header, []byte, error) {
 	parts := strings.Split(token, ".")
 	if len(parts) != 3 {
-		return Payload{}, errInvalidTokenFormat
+		return Header{}, nil, ErrInvalidTokenFormat
 	}
 
-	tokenToSign := fmt.Sprintf("%s.%s", parts[0], parts[1])
+	headerBytes, err := inst.decodeSegment(parts[0])
+	if err != nil {
+		return Header{}, nil, ErrInvalidTokenHeader
+	}
 
-	mac := hmac.New(sha256.New, secretKey)
-	mac.Write([]byte(tokenToSign))
-	expectedSignature := base64.URLEncoding.EncodeToString(mac.Sum(nil))
+	var header Header
+	err = json.Unmarshal(headerBytes, &header)
+	if err != nil {
+		return Header{}, nil, ErrInvalidTokenHeader
+	}
+
+	signature, err := inst.decodeSegment(parts[2])
+	if err != nil {
+		return Header{}, nil, ErrInvalidTokenSignature
+	}
 
-	if parts[2] != expectedSignature {
-		return Payload{}, errInvalidTokenSignature
+	if err := inst.verifySignature(&header, parts[0]+"."+parts[1], signature); err != nil {
+		return Header{}, nil, err
 	}
 
-	payloadBytes, err := base64.URLEncoding.DecodeString(parts[1])
+	payloadBytes, err := inst.decodePayload(&header, parts[1])
 	if err != nil {
-		return Payload{}, errInvalidTokenPayload
+		return Header{}, nil, ErrInvalidTokenPayload
 	}
 
-	var payload Payload
-	err = json.Unmarshal(payloadBytes, &payload)
+	return header, payloadBytes, nil
+}
+
+// verifySignature checks the signature of a token with the given header and signing input,
+// using the verification key, key source or certificate roots of the instance
+func (inst *Instance) verifySignature(header *Header, signingInput string, signature []byte) error {
+	if header.unencoded() && !header.isCritical("b64") {
+		return ErrInvalidTokenHeader
+	}
+	if err := inst.checkCritical(header); err != nil {
+		return err
+	}
+
+	alg, err := inst.lookupAlgorithm(header.Algorithm)
 	if err != nil {
-		return Payload{}, errInvalidTokenPayload
+		return err
+	}
+	if inst.allowedAlgs != nil && !containsString(inst.allowedAlgs, header.Algorithm) {
+		return ErrAlgorithmNotAllowed
 	}
 
-	if time.Now().Unix() > payload.Expires.Unix() {
-		return Payload{}, errTokenExpired
+	if _, ok := alg.(noneAlgorithm); ok {
+		if err := alg.verify(nil, []byte(signingInput), signature); err != nil {
+			return err
+		}
+		return inst.handleCritical(header)
 	}
 
-	return payload, nil
+	allowed := inst.allowedAlgs != nil || header.Algorithm == inst.algorithm
+	verificationKey := inst.verificationKey
+	if inst.certRoots != nil {
+		verificationKey, err = inst.certificateChainKey(header)
+		if err != nil {
+			return err
+		}
+	} else if inst.keySource != nil {
+		key, err := inst.keySource.LookupKey(header)
+		if err != nil {
+			return err
+		}
+		if key.Algorithm != "" && key.Algorithm != header.Algorithm {
+			return ErrKeyTypeMismatch
+		}
+		allowed = allowed || key.algorithm() == header.Algorithm
+		verificationKey = publicKey(key.Key)
+	}
+	if !allowed {
+		return ErrAlgorithmNotAllowed
+	}
+	if keyType(verificationKey) != alg.keyType() {
+		return ErrKeyTypeMismatch
+	}
+
+	if inst.strictKeyLength {
+		if err := checkKeyLength(alg, verificationKey); err != nil {
+			return err
+		}
+	}
+
+	if err := alg.verify(verificationKey, []byte(signingInput), signature); err != nil {
+		return err
+	}
+
+	return inst.handleCritical(header)
+}
+
+// lookupAlgorithm returns the algorithm for the given "alg" header value.
+// "none" is only returned if WithInsecureNoneAlgorithm is used.
+// PSS signatures with any salt length are only accepted if WithAnyPSSSaltLength is used.
+func (inst *Instance) lookupAlgorithm(name string) (algorithm, error) {
+	if name == "none" {
+		if !inst.allowNone {
+			return nil, ErrAlgorithmNotAllowed
+		}
+		return noneAlgorithm{}, nil
+	}
+	alg, err := lookupAlgorithm(name)
+	if pss, ok := alg.(rsaPSSAlgorithm); ok && inst.anyPSSSalt {
+		pss.anySaltLength = true
+		return pss, nil
+	}
+	return alg, err
+}
+
+// checkType checks the "typ" header of a token, if WithRequiredType is used
+func (inst *Instance) checkType(header *Header) error {
+	if inst.requiredType != "" && !mediaTypeEqual(header.Type, inst.requiredType) {
+		return ErrInvalidTokenType
+	}
+	return nil
+}
+
+// mediaTypeEqual compares two "typ" or "cty" header values case-insensitively.
+// The "application/" prefix can be left out, as described in RFC 7515 section 4.1.9.
+func mediaTypeEqual(a, b string) bool {
+	trim := func(s string) string {
+		s = strings.ToLower(s)
+		if rest := strings.TrimPrefix(s, "application/"); !strings.Contains(rest, "/") {
+			return rest
+		}
+		return s
+	}
+	return a != "" && trim(a) == trim(b)
+}
+
+// containsString returns true if the given string is in the slice
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
 }
 
 // SimpleGenerate takes a payload subject and the number of seconds forward in time the generated token should be valid for.
 // A string is returns that is either empty (if there were errors), or contains the generated JWT token.
-func SimpleGenerate(subject string, seconds int) string {
-	token, err := Generate(Payload{subject, time.Now().AddDate(0, 0, seconds)}, nil)
+// Note that the number is counted as days, not seconds, so that existing callers keep the same token lifetime.
+func (inst *Instance) SimpleGenerate(subject string, seconds int) string {
+	token, err := inst.Generate(Payload{Subject: subject, Expires: inst.clock.Now().AddDate(0, 0, seconds)}, nil)
 	if err != nil {
 		return ""
 	}
@@ -120,10 +681,54 @@ func SimpleGenerate(subject string, seconds int) string {
 // SimpleValidate checks if the given JWT token is valid.
 // If it is, the suject of the payload is returned.
 // If not, an empty string is returned.
-func SimpleValidate(token string) string {
-	payload, err := Validate(token)
+func (inst *Instance) SimpleValidate(token string) string {
+	payload, err := inst.Validate(token)
 	if err != nil {
 		return ""
 	}
 	return payload.Subject
 }
+
+// Generate generates a JWT token with the provided payload and an optional custom header,
+// using the default instance that is configured with SetSecret and SetDefault.
+func Generate(payload Payload, customHeader *Header) (string, error) {
+	return defaultInstance.Generate(payload, customHeader)
+}
+
+// Validate validates a JWT token and returns the decoded payload if the token is valid,
+// using the default instance that is configured with SetSecret and SetDefault.
+func Validate(token string) (Payload, error) {
+	return defaultInstance.Validate(token)
+}
+
+// GenerateClaims generates a JWT token with arbitrary claims and an optional custom header,
+// using the default instance that is configured with SetSecret and SetDefault.
+func GenerateClaims(claims any, customHeader *Header) (string, error) {
+	return defaultInstance.GenerateClaims(claims, customHeader)
+}
+
+// ValidateInto validates a JWT token and decodes the claims into the value pointed to by claims,
+// using the default instance that is configured with SetSecret and SetDefault.
+func ValidateInto(token string, claims any) error {
+	return defaultInstance.ValidateInto(token, claims)
+}
+
+// ValidateClaims validates a JWT token and returns all of its claims as a map,
+// using the default instance that is configured with SetSecret and SetDefault.
+func ValidateClaims(token string) (Claims, error) {
+	return defaultInstance.ValidateClaims(token)
+}
+
+// SimpleGenerate takes a payload subject and the number of seconds forward in time the generated token should be valid for.
+// A string is returns that is either empty (if there were errors), or contains the generated JWT token.
+// Note that the number is counted as days, not seconds, so that existing callers keep the same token lifetime.
+func SimpleGenerate(subject string, seconds int) string {
+	return defaultInstance.SimpleGenerate(subject, seconds)
+}
+
+// SimpleValidate checks if the given JWT token is valid.
+// If it is, the suject of the payload is returned.
+// If not, an empty string is returned.
+func SimpleValidate(token string) string {
+	return defaultInstance.SimpleValidate(token)
+}
@@ -1,6 +1,14 @@
 package simplejwt_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -36,3 +44,222 @@ func TestSimpleJWT(t *testing.T) {
 		t.Errorf("Expected Expires to be %v, got %v", payload.Expires, decodedPayload.Expires)
 	}
 }
+
+func TestSetSecretKeepsOptions(t *testing.T) {
+	defer simplejwt.SetDefault(simplejwt.WithSecret("testsecret"))
+
+	simplejwt.SetDefault(simplejwt.WithSecret("secret-a"), simplejwt.WithRequiredIssuer("https://auth.example.com"))
+	simplejwt.SetSecret("secret-b")
+
+	payload := simplejwt.Payload{Subject: "alice", Expires: time.Now().Add(time.Hour)}
+	token, err := simplejwt.Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := simplejwt.Validate(token); !errors.Is(err, simplejwt.ErrInvalidIssuer) {
+		t.Errorf("Expected the required issuer to be kept, got %v", err)
+	}
+	payload.Issuer = "https://auth.example.com"
+	token, err = simplejwt.Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := simplejwt.New(simplejwt.WithSecret("secret-b")).Validate(token); err != nil {
+		t.Errorf("Expected the token to be signed with the new secret: %v", err)
+	}
+
+	// The secret replaces a key for another algorithm
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	simplejwt.SetDefault(simplejwt.WithSigningKey(simplejwt.ES256, key))
+	simplejwt.SetSecret("secret-b")
+	token, err = simplejwt.Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := simplejwt.New(simplejwt.WithSecret("secret-b")).Validate(token); err != nil {
+		t.Errorf("Expected the token to be signed with the new secret: %v", err)
+	}
+}
+
+func TestInstances(t *testing.T) {
+	// Two instances with different secrets, used side by side
+	a := simplejwt.New(simplejwt.WithSecret("secret-a"))
+	b := simplejwt.New(simplejwt.WithSecret("secret-b"))
+
+	payload := simplejwt.Payload{
+		Subject: "alice",
+		Expires: time.Now().Add(time.Hour),
+	}
+
+	token, err := a.Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	if _, err := a.Validate(token); err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+
+	if _, err := b.Validate(token); err == nil {
+		t.Errorf("Expected token signed with another secret to be rejected")
+	}
+
+	if subject := a.SimpleValidate(a.SimpleGenerate("bob", 60)); subject != "bob" {
+		t.Errorf("Expected Subject to be bob, got %s", subject)
+	}
+
+	// Instances without a key do not fall back to the secret of the package-level functions
+	bare := simplejwt.New()
+	if _, err := bare.Generate(payload, nil); !errors.Is(err, simplejwt.ErrKeyTypeMismatch) {
+		t.Errorf("Expected ErrKeyTypeMismatch when generating without a key, got %v", err)
+	}
+	legacy, err := simplejwt.New(simplejwt.WithSecret("your-secret-key")).Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := bare.Validate(legacy); !errors.Is(err, simplejwt.ErrKeyTypeMismatch) {
+		t.Errorf("Expected ErrKeyTypeMismatch when validating without a key, got %v", err)
+	}
+}
+
+// mustDecodeSegment decodes the given base64url encoded part of a token
+func mustDecodeSegment(t *testing.T, token string, index int) string {
+	t.Helper()
+	segment := strings.Split(token, ".")[index]
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		t.Fatalf("Failed to decode token segment: %v", err)
+	}
+	return string(data)
+}
+
+func TestLegacyPadding(t *testing.T) {
+	jwt := simplejwt.New(simplejwt.WithSecret("testsecret"))
+
+	token, err := jwt.Generate(simplejwt.Payload{Subject: "alice", Expires: time.Now().Add(time.Hour)}, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if strings.Contains(token, "=") {
+		t.Errorf("Expected token without padding, got %s", token)
+	}
+
+	// Generate a token the way earlier versions did, with padded base64url
+	header := base64.URLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload := base64.URLEncoding.EncodeToString([]byte(`{"sub":"alice","exp":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`))
+	mac := hmac.New(sha256.New, []byte("testsecret"))
+	mac.Write([]byte(header + "." + payload))
+	legacyToken := header + "." + payload + "." + base64.URLEncoding.EncodeToString(mac.Sum(nil))
+
+	if _, err := jwt.Validate(legacyToken); err == nil {
+		t.Errorf("Expected padded token to be rejected by default")
+	}
+
+	decodedPayload, err := simplejwt.New(simplejwt.WithSecret("testsecret"), simplejwt.WithLegacyPadding()).Validate(legacyToken)
+	if err != nil {
+		t.Fatalf("Failed to validate padded token: %v", err)
+	}
+	if decodedPayload.Subject != "alice" {
+		t.Errorf("Expected Subject to be alice, got %s", decodedPayload.Subject)
+	}
+}
+
+func TestTokenType(t *testing.T) {
+	secret := strings.Repeat("s", 32)
+	payload := simplejwt.Payload{
+		Subject: "1234567890",
+		Expires: time.Now().Add(time.Hour),
+	}
+
+	accessTokens := simplejwt.New(simplejwt.WithSecret(secret), simplejwt.WithType("at+jwt"))
+	accessToken, err := accessTokens.Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate access token: %v", err)
+	}
+	if header := mustDecodeSegment(t, accessToken, 0); !strings.Contains(header, `"typ":"at+jwt"`) {
+		t.Errorf("Expected typ at+jwt, got %s", header)
+	}
+	idToken, err := simplejwt.New(simplejwt.WithSecret(secret)).Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	untyped, err := simplejwt.New(simplejwt.WithSecret(secret)).Generate(payload, &simplejwt.Header{})
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	// The comparison is case-insensitive, and the application/ prefix is optional
+	for _, required := range []string{"at+jwt", "AT+JWT", "application/at+jwt"} {
+		validator := simplejwt.New(simplejwt.WithSecret(secret), simplejwt.WithRequiredType(required))
+		if _, err := validator.Validate(accessToken); err != nil {
+			t.Errorf("Failed to validate access token when requiring %s: %v", required, err)
+		}
+		if _, err := validator.Validate(idToken); !errors.Is(err, simplejwt.ErrInvalidTokenType) {
+			t.Errorf("Expected ErrInvalidTokenType for a JWT token when requiring %s, got %v", required, err)
+		}
+		if _, err := validator.Validate(untyped); !errors.Is(err, simplejwt.ErrInvalidTokenType) {
+			t.Errorf("Expected ErrInvalidTokenType for a token without typ when requiring %s, got %v", required, err)
+		}
+	}
+
+	// A nested JWT, with "cty" set to "JWT", is validated all the way to the innermost token
+	outer := simplejwt.New(simplejwt.WithSecret(secret))
+	nested, err := outer.GenerateNested(accessToken, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate nested token: %v", err)
+	}
+	if header := mustDecodeSegment(t, nested, 0); !strings.Contains(header, `"cty":"JWT"`) {
+		t.Errorf("Expected cty JWT, got %s", header)
+	}
+	validator := simplejwt.New(simplejwt.WithSecret(secret), simplejwt.WithRequiredType("at+jwt"))
+	validated, err := validator.Validate(nested)
+	if err != nil {
+		t.Fatalf("Failed to validate nested token: %v", err)
+	}
+	if validated.Subject != payload.Subject {
+		t.Errorf("Expected subject %s, got %s", payload.Subject, validated.Subject)
+	}
+	nestedID, err := outer.GenerateNested(idToken, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate nested token: %v", err)
+	}
+	if _, err := validator.Validate(nestedID); !errors.Is(err, simplejwt.ErrInvalidTokenType) {
+		t.Errorf("Expected ErrInvalidTokenType for a nested JWT token, got %v", err)
+	}
+
+	// The same goes for detached nested tokens
+	detached, err := outer.SignDetached([]byte(accessToken), &simplejwt.Header{ContentType: "JWT"})
+	if err != nil {
+		t.Fatalf("Failed to sign detached nested token: %v", err)
+	}
+	if err := validator.ValidateDetached(detached, []byte(accessToken), &validated); err != nil {
+		t.Errorf("Failed to validate detached nested token: %v", err)
+	}
+	if err := validator.VerifyDetached(detached, []byte(accessToken)); err != nil {
+		t.Errorf("Failed to verify detached nested token: %v", err)
+	}
+	detached, err = outer.SignDetached([]byte(idToken), &simplejwt.Header{ContentType: "JWT"})
+	if err != nil {
+		t.Fatalf("Failed to sign detached nested token: %v", err)
+	}
+	if err := validator.VerifyDetached(detached, []byte(idToken)); !errors.Is(err, simplejwt.ErrInvalidTokenType) {
+		t.Errorf("Expected ErrInvalidTokenType for a detached nested JWT token, got %v", err)
+	}
+}
+
+func TestSimpleGenerateLifetime(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	jwt := simplejwt.New(simplejwt.WithSecret("secret"), simplejwt.WithClock(simplejwt.ClockFunc(func() time.Time { return now })))
+
+	// The lifetime is counted in days, like it always has been
+	parsed, err := simplejwt.ParseUnverified(jwt.SimpleGenerate("bob", 3600))
+	if err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+	if expires := now.AddDate(0, 0, 3600); parsed.Claims["exp"] != float64(expires.Unix()) {
+		t.Errorf("Expected the token to expire after 3600 days, at %d, got %v", expires.Unix(), parsed.Claims["exp"])
+	}
+}
@@ -0,0 +1,58 @@
+package simplejwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// UnverifiedToken is a token that has been decoded without checking its signature or claims.
+// Nothing in it can be trusted, and it must only be used for deciding how to validate the token,
+// such as choosing a validator from the "iss" claim or the "kid" header.
+type UnverifiedToken struct {
+	Header Header
+	Claims Claims
+
+	// Segments holds the header, payload and signature parts of the token, as they are in the token
+	Segments [3]string
+}
+
+// ParseUnverified decodes the header and the claims of a token WITHOUT verifying it.
+// The signature, the algorithm and the claims are not checked at all. Use Validate,
+// ValidateInto or ValidateClaims on an Instance for that.
+// If the payload is detached, or is a nested JWT, Claims is nil.
+func ParseUnverified(token string) (*UnverifiedToken, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidTokenFormat
+	}
+
+	parsed := &UnverifiedToken{Segments: [3]string{parts[0], parts[1], parts[2]}}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[0], "="))
+	if err != nil {
+		return nil, ErrInvalidTokenHeader
+	}
+	if err := json.Unmarshal(headerBytes, &parsed.Header); err != nil {
+		return nil, ErrInvalidTokenHeader
+	}
+
+	if parts[1] == "" {
+		return parsed, nil
+	}
+	payloadBytes := []byte(parts[1])
+	if !parsed.Header.unencoded() {
+		payloadBytes, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+		if err != nil {
+			return nil, ErrInvalidTokenPayload
+		}
+	}
+	if mediaTypeEqual(parsed.Header.ContentType, "JWT") {
+		return parsed, nil
+	}
+	if err := json.Unmarshal(payloadBytes, &parsed.Claims); err != nil {
+		return nil, ErrInvalidTokenPayload
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,55 @@
+package simplejwt_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xyproto/simplejwt"
+)
+
+func TestParseUnverified(t *testing.T) {
+	issuer := simplejwt.New(simplejwt.WithSecret(strings.Repeat("s", 32)))
+	claims := simplejwt.Claims{
+		"iss": "https://issuer.example.com",
+		"sub": "alice",
+		"exp": simplejwt.NewNumericDate(time.Now().Add(-time.Hour)),
+	}
+	token, err := issuer.GenerateClaims(claims, &simplejwt.Header{KeyID: "key-1", Type: "JWT"})
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	// Expired tokens, and tokens with any signature, are parsed
+	forged := token[:strings.LastIndex(token, ".")+1] + "c2lnbmF0dXJl"
+	for _, raw := range []string{token, forged} {
+		parsed, err := simplejwt.ParseUnverified(raw)
+		if err != nil {
+			t.Fatalf("Failed to parse token: %v", err)
+		}
+		if parsed.Header.KeyID != "key-1" || parsed.Header.Algorithm != simplejwt.HS256 {
+			t.Errorf("Expected kid key-1 and alg HS256, got %+v", parsed.Header)
+		}
+		if parsed.Claims["iss"] != "https://issuer.example.com" {
+			t.Errorf("Expected the iss claim, got %v", parsed.Claims["iss"])
+		}
+		if strings.Join(parsed.Segments[:], ".") != raw {
+			t.Errorf("Expected the segments to make up the token, got %v", parsed.Segments)
+		}
+	}
+
+	// Parsing says nothing about validity
+	if _, err := issuer.Validate(forged); err == nil {
+		t.Error("Expected the forged token to be invalid")
+	}
+
+	for _, raw := range []string{"", "a.b", "a.b.c.d", "!!!.e30.", "e30.!!!."} {
+		if _, err := simplejwt.ParseUnverified(raw); err == nil {
+			t.Errorf("Expected an error for %q", raw)
+		}
+	}
+	if _, err := simplejwt.ParseUnverified("a.b"); !errors.Is(err, simplejwt.ErrInvalidTokenFormat) {
+		t.Errorf("Expected ErrInvalidTokenFormat, got %v", err)
+	}
+}
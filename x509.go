@@ -0,0 +1,69 @@
+package simplejwt
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+)
+
+// setCertificateChain sets the "x5c" and "x5t#S256" headers for the given certificate chain
+func setCertificateChain(header *Header, certs []*x509.Certificate) {
+	header.X509CertChain = make([]string, len(certs))
+	for i, cert := range certs {
+		// x5c uses standard base64, not base64url
+		header.X509CertChain[i] = base64.StdEncoding.EncodeToString(cert.Raw)
+	}
+	thumbprint := sha256.Sum256(certs[0].Raw)
+	header.X509ThumbprintS256 = encodeBytes(thumbprint[:])
+}
+
+// certificateChainKey verifies the certificate chain in the "x5c" header against the
+// roots of the instance, and returns the public key of the first certificate
+func (inst *Instance) certificateChainKey(header *Header) (any, error) {
+	if len(header.X509CertChain) == 0 {
+		return nil, ErrKeyNotFound
+	}
+	certs := make([]*x509.Certificate, len(header.X509CertChain))
+	for i, encoded := range header.X509CertChain {
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, ErrInvalidCertificateChain
+		}
+		certs[i], err = x509.ParseCertificate(der)
+		if err != nil {
+			return nil, ErrInvalidCertificateChain
+		}
+	}
+	leaf := certs[0]
+
+	if header.X509ThumbprintS256 != "" {
+		thumbprint := sha256.Sum256(leaf.Raw)
+		if subtle.ConstantTimeCompare([]byte(encodeBytes(thumbprint[:])), []byte(header.X509ThumbprintS256)) != 1 {
+			return nil, ErrInvalidCertificateChain
+		}
+	}
+	if header.X509Thumbprint != "" {
+		thumbprint := sha1.Sum(leaf.Raw)
+		if subtle.ConstantTimeCompare([]byte(encodeBytes(thumbprint[:])), []byte(header.X509Thumbprint)) != 1 {
+			return nil, ErrInvalidCertificateChain
+		}
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         inst.certRoots,
+		Intermediates: intermediates,
+		CurrentTime:   inst.clock.Now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, ErrInvalidCertificateChain
+	}
+
+	return supportedPublicKey(leaf.PublicKey)
+}
@@ -0,0 +1,103 @@
+package simplejwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xyproto/simplejwt"
+)
+
+// newCertificate creates a certificate and its key, signed by the given parent.
+// If parent is nil, the certificate is self-signed.
+func newCertificate(t *testing.T, name string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return cert, key
+}
+
+func TestCertificateChain(t *testing.T) {
+	root, rootKey := newCertificate(t, "root", true, nil, nil)
+	intermediate, intermediateKey := newCertificate(t, "intermediate", true, root, rootKey)
+	leaf, leafKey := newCertificate(t, "partner", false, intermediate, intermediateKey)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	partner := simplejwt.New(simplejwt.WithSigningKey(simplejwt.ES256, leafKey), simplejwt.WithCertificateChain([]*x509.Certificate{leaf, intermediate}))
+	payload := simplejwt.Payload{Subject: "alice", Expires: time.Now().Add(time.Hour)}
+
+	token, err := partner.Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if header := mustDecodeSegment(t, token, 0); !strings.Contains(header, `"x5c":[`) || !strings.Contains(header, `"x5t#S256":`) {
+		t.Errorf("Expected x5c and x5t#S256 headers, got %s", header)
+	}
+
+	verifier := simplejwt.New(simplejwt.WithCertificateRoots(roots), simplejwt.WithAlgorithm(simplejwt.ES256))
+	if _, err := verifier.Validate(token); err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+
+	// A chain that does not lead to a trusted root
+	otherRoot, otherRootKey := newCertificate(t, "other root", true, nil, nil)
+	otherLeaf, otherLeafKey := newCertificate(t, "impostor", false, otherRoot, otherRootKey)
+	impostor := simplejwt.New(simplejwt.WithSigningKey(simplejwt.ES256, otherLeafKey), simplejwt.WithCertificateChain([]*x509.Certificate{otherLeaf}))
+	token, err = impostor.Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := verifier.Validate(token); !errors.Is(err, simplejwt.ErrInvalidCertificateChain) {
+		t.Errorf("Expected ErrInvalidCertificateChain, got %v", err)
+	}
+
+	// A trusted chain, but signed with another key
+	forger := simplejwt.New(simplejwt.WithSigningKey(simplejwt.ES256, otherLeafKey), simplejwt.WithCertificateChain([]*x509.Certificate{leaf, intermediate}))
+	token, err = forger.Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := verifier.Validate(token); !errors.Is(err, simplejwt.ErrInvalidTokenSignature) {
+		t.Errorf("Expected ErrInvalidTokenSignature, got %v", err)
+	}
+
+	// A token without a certificate chain
+	token, err = simplejwt.New(simplejwt.WithSigningKey(simplejwt.ES256, leafKey)).Generate(payload, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := verifier.Validate(token); err == nil {
+		t.Errorf("Expected a token without x5c to be rejected")
+	}
+}